@@ -3,6 +3,7 @@ package compression
 import (
 	"bytes"
 	"compress/gzip"
+	"errors"
 	"fmt"
 	"io"
 
@@ -16,6 +17,9 @@ const (
 	LZ4  Algorithm = "lz4"
 )
 
+// ErrSizeLimit is returned by DecompressLimit when the data expands beyond the limit.
+var ErrSizeLimit = errors.New("decompressed data exceeds size limit")
+
 type Compressor struct {
 	algorithm Algorithm
 }
@@ -46,6 +50,34 @@ func (c *Compressor) Decompress(data []byte) ([]byte, error) {
 	}
 }
 
+// DecompressLimit decompresses untrusted data, failing with ErrSizeLimit
+// instead of expanding it beyond limit bytes.
+func (c *Compressor) DecompressLimit(data []byte, limit int) ([]byte, error) {
+	var reader io.Reader
+	switch c.algorithm {
+	case Gzip:
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		reader = gz
+	case LZ4:
+		reader = lz4.NewReader(bytes.NewReader(data))
+	default:
+		return nil, fmt.Errorf("unsupported algorithm: %s", c.algorithm)
+	}
+
+	out, err := io.ReadAll(io.LimitReader(reader, int64(limit)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(out) > limit {
+		return nil, ErrSizeLimit
+	}
+	return out, nil
+}
+
 func (c *Compressor) compressGzip(data []byte) ([]byte, error) {
 	var buf bytes.Buffer
 	writer := gzip.NewWriter(&buf)
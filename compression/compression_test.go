@@ -1,6 +1,8 @@
 package compression
 
 import (
+	"bytes"
+	"errors"
 	"testing"
 )
 
@@ -74,3 +76,21 @@ func TestCompressionRatio(t *testing.T) {
 	t.Logf("Compression ratio: %.2f (Original: %d, Compressed: %d)", 
 		ratio, originalSize, compressedSize)
 }
+
+func TestDecompressLimit(t *testing.T) {
+	for _, algorithm := range []Algorithm{Gzip, LZ4} {
+		compressor := NewCompressor(algorithm)
+		compressed, err := compressor.Compress(bytes.Repeat([]byte("a"), 1<<20))
+		if err != nil {
+			t.Fatalf("%s: compression failed: %v", algorithm, err)
+		}
+
+		if _, err := compressor.DecompressLimit(compressed, 1<<10); !errors.Is(err, ErrSizeLimit) {
+			t.Errorf("%s: expected ErrSizeLimit, got %v", algorithm, err)
+		}
+		decompressed, err := compressor.DecompressLimit(compressed, 1<<20)
+		if err != nil || len(decompressed) != 1<<20 {
+			t.Errorf("%s: decompression at the limit failed: %v", algorithm, err)
+		}
+	}
+}
@@ -4,6 +4,9 @@ go 1.23.0
 
 toolchain go1.23.9
 
-require golang.org/x/net v0.40.0
+require (
+	github.com/pierrec/lz4/v4 v4.1.31
+	golang.org/x/net v0.40.0
+)
 
 require golang.org/x/sys v0.33.0 // indirect
@@ -0,0 +1,128 @@
+package multicast
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// hostFilter selects members by the query parameters of an admin request:
+// hostname (prefix), version and any number of label=key or label=key=value.
+type hostFilter struct {
+	hostnamePrefix string
+	version        string
+	labels         map[string]*string
+}
+
+func parseHostFilter(r *http.Request) hostFilter {
+	q := r.URL.Query()
+	f := hostFilter{
+		hostnamePrefix: q.Get("hostname"),
+		version:        q.Get("version"),
+		labels:         make(map[string]*string),
+	}
+	for _, label := range q["label"] {
+		key, value, hasValue := strings.Cut(label, "=")
+		if hasValue {
+			f.labels[key] = &value
+		} else {
+			f.labels[key] = nil
+		}
+	}
+	return f
+}
+
+func (f hostFilter) match(m Member) bool {
+	if !strings.HasPrefix(m.Hostname, f.hostnamePrefix) {
+		return false
+	}
+	if f.version != "" && m.Version != f.version {
+		return false
+	}
+	for key, want := range f.labels {
+		got, ok := m.Labels[key]
+		if !ok || (want != nil && got != *want) {
+			return false
+		}
+	}
+	return true
+}
+
+func AdminHandler() http.Handler {
+	return defaultNode.AdminHandler()
+}
+
+// AdminHandler serves the membership table as JSON. Requests to a path
+// ending in /events stream membership changes as Server-Sent Events instead.
+// Both accept the hostname, version and label filters.
+func (n *Node) AdminHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		filter := parseHostFilter(r)
+		if strings.HasSuffix(r.URL.Path, "/events") {
+			n.serveEvents(w, r, filter)
+			return
+		}
+		n.serveHosts(w, filter)
+	})
+}
+
+func (n *Node) serveHosts(w http.ResponseWriter, filter hostFilter) {
+	hosts := make([]Member, 0)
+	for _, m := range n.Members() {
+		if filter.match(m) {
+			hosts = append(hosts, m)
+		}
+	}
+	sort.Slice(hosts, func(i, j int) bool {
+		return hosts[i].Hostname < hosts[j].Hostname
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(hosts)
+}
+
+func (n *Node) serveEvents(w http.ResponseWriter, r *http.Request, filter hostFilter) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	events, cancel := n.Subscribe(64)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case ev, ok := <-events:
+			if !ok {
+				return
+			}
+			if !filter.match(ev.Member) {
+				continue
+			}
+			data, err := json.Marshal(ev.Member)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package multicast
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAdminHandlerFilters(t *testing.T) {
+	n := NewNode()
+	now := time.Now()
+	n.observe(HostInfoReceiver{Hostname: "web-1", Version: "1.0", Labels: map[string]string{"role": "web"}}, now)
+	n.observe(HostInfoReceiver{Hostname: "web-2", Version: "2.0", Labels: map[string]string{"role": "web"}}, now)
+	n.observe(HostInfoReceiver{Hostname: "db-1", Version: "1.0", Labels: map[string]string{"role": "db"}}, now)
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{"db-1", "web-1", "web-2"}},
+		{"hostname=web", []string{"web-1", "web-2"}},
+		{"version=1.0", []string{"db-1", "web-1"}},
+		{"label=role=db", []string{"db-1"}},
+		{"label=role&version=2.0", []string{"web-2"}},
+		{"label=zone", []string{}},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		n.AdminHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/hosts?"+tt.query, nil))
+
+		var hosts []Member
+		if err := json.NewDecoder(rec.Body).Decode(&hosts); err != nil {
+			t.Fatalf("%q: invalid response: %v", tt.query, err)
+		}
+		var got []string
+		for _, h := range hosts {
+			got = append(got, h.Hostname)
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%q: got %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestAdminHandlerStreamsEvents(t *testing.T) {
+	n := NewNode()
+	srv := httptest.NewServer(n.AdminHandler())
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/hosts/events?hostname=web", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	n.observe(HostInfoReceiver{Hostname: "db-1"}, time.Now())
+	n.observe(HostInfoReceiver{Hostname: "web-1"}, time.Now())
+
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if line != "event: join\n" {
+		t.Errorf("unexpected event line %q", line)
+	}
+}
@@ -0,0 +1,101 @@
+package multicast
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"time"
+)
+
+type announceConfig struct {
+	interval time.Duration
+	jitter   float64
+	info     *HostInfoReceiver
+}
+
+type AnnounceOption func(*announceConfig)
+
+// WithAnnounceInterval sets the mean time between announcements (default 5s).
+func WithAnnounceInterval(interval time.Duration) AnnounceOption {
+	return func(c *announceConfig) {
+		c.interval = interval
+	}
+}
+
+// WithAnnounceJitter randomizes each interval by up to ±fraction of itself so
+// hosts started together do not announce in lockstep (default 0.2).
+func WithAnnounceJitter(fraction float64) AnnounceOption {
+	return func(c *announceConfig) {
+		c.jitter = fraction
+	}
+}
+
+// WithHostInfo sets the local host info to announce, as Node.SetLocalInfo
+// does. Hostname and IPs are always filled in by the announcer.
+func WithHostInfo(info HostInfoReceiver) AnnounceOption {
+	return func(c *announceConfig) {
+		c.info = &info
+	}
+}
+
+func Announce(ctx context.Context, addr string, opts ...AnnounceOption) error {
+	return defaultNode.Announce(ctx, addr, opts...)
+}
+
+// Announce multicasts this host's info to addr every interval until ctx is
+// cancelled. Interface IPs are re-read for every announcement, and changes
+// made with SetLocalInfo or SetLabel are announced immediately.
+func (n *Node) Announce(ctx context.Context, addr string, opts ...AnnounceOption) error {
+	cfg := announceConfig{
+		interval: 5 * time.Second,
+		jitter:   0.2,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.interval <= 0 {
+		return fmt.Errorf("announce interval must be positive")
+	}
+	if cfg.jitter < 0 || cfg.jitter >= 1 {
+		return fmt.Errorf("announce jitter must be in [0, 1)")
+	}
+
+	hostname := n.hostname
+	if hostname == "" {
+		return fmt.Errorf("hostname unknown: set it with WithHostname")
+	}
+
+	n.announcers.Add(1)
+	defer n.announcers.Add(-1)
+	if cfg.info != nil {
+		n.setLocalInfo(*cfg.info)
+	}
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-timer.C:
+		case <-n.localChanged:
+		}
+
+		info := n.hostInfo(hostname)
+		n.observe(info, time.Now())
+
+		if err := n.sendEnvelope(ctx, addr, MessageEnvelope{Type: "hostinfo", Payload: info}); err != nil {
+			log.Printf("Announce failed: %v", err)
+		}
+		timer.Reset(jitter(cfg.interval, cfg.jitter))
+	}
+}
+
+func jitter(d time.Duration, fraction float64) time.Duration {
+	if fraction == 0 {
+		return d
+	}
+	return d + time.Duration((rand.Float64()*2-1)*fraction*float64(d))
+}
@@ -0,0 +1,141 @@
+package multicast
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestJitterStaysWithinBounds(t *testing.T) {
+	interval := time.Second
+	for i := 0; i < 1000; i++ {
+		d := jitter(interval, 0.2)
+		if d < 800*time.Millisecond || d > 1200*time.Millisecond {
+			t.Fatalf("jittered interval %s out of bounds", d)
+		}
+	}
+	if d := jitter(interval, 0); d != interval {
+		t.Errorf("expected no jitter, got %s", d)
+	}
+}
+
+// announceRecorder captures the host info a node announces.
+type announceRecorder struct {
+	sent chan HostInfoReceiver
+}
+
+func recordAnnouncements(n *Node) *announceRecorder {
+	r := &announceRecorder{sent: make(chan HostInfoReceiver, 100)}
+	n.send = func(ctx context.Context, addr string, env MessageEnvelope) error {
+		if info, ok := env.Payload.(HostInfoReceiver); ok && env.Type == "hostinfo" {
+			r.sent <- info
+		}
+		return nil
+	}
+	return r
+}
+
+func (r *announceRecorder) next(t *testing.T, within time.Duration) HostInfoReceiver {
+	t.Helper()
+	select {
+	case info := <-r.sent:
+		return info
+	case <-time.After(within):
+		t.Fatal("no announcement")
+		return HostInfoReceiver{}
+	}
+}
+
+func TestAnnounceRereadsIPs(t *testing.T) {
+	n := NewNode(WithHostname("h1"))
+	rec := recordAnnouncements(n)
+	var reads atomic.Int32
+	n.localAddrs = func() []string {
+		return []string{fmt.Sprintf("192.0.2.%d/24", reads.Add(1))}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go n.Announce(ctx, "239.0.0.1:9999", WithAnnounceInterval(10*time.Millisecond), WithAnnounceJitter(0))
+
+	// 시작 즉시 한 번, 이후 매 주기마다 IP 를 새로 읽음
+	first := rec.next(t, 50*time.Millisecond)
+	second := rec.next(t, time.Second)
+	if first.Hostname != "h1" || first.IPs[0] == second.IPs[0] {
+		t.Errorf("IPs not re-read: %v then %v", first.IPs, second.IPs)
+	}
+}
+
+func TestAnnounceOnLocalChange(t *testing.T) {
+	n := NewNode(WithHostname("h1"))
+	rec := recordAnnouncements(n)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go n.Announce(ctx, "239.0.0.1:9999", WithAnnounceInterval(time.Hour))
+	rec.next(t, time.Second)
+
+	n.SetLabel("role", "db")
+	if info := rec.next(t, time.Second); info.Labels["role"] != "db" {
+		t.Errorf("change announced without the new label: %v", info.Labels)
+	}
+}
+
+func TestAnnounceStopsOnCancel(t *testing.T) {
+	n := NewNode(WithHostname("h1"))
+	rec := recordAnnouncements(n)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- n.Announce(ctx, "239.0.0.1:9999", WithAnnounceInterval(10*time.Millisecond)) }()
+	rec.next(t, time.Second)
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Announce returned %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Announce did not stop on cancel")
+	}
+	if n.announcers.Load() != 0 {
+		t.Errorf("announcer still registered")
+	}
+
+	for len(rec.sent) > 0 {
+		<-rec.sent
+	}
+	time.Sleep(30 * time.Millisecond)
+	if len(rec.sent) != 0 {
+		t.Errorf("announced after cancel")
+	}
+}
+
+func TestAnnounceDefaultHostname(t *testing.T) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		t.Skipf("no hostname: %v", err)
+	}
+	n := NewNode()
+	rec := recordAnnouncements(n)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go n.Announce(ctx, "239.0.0.1:9999", WithAnnounceInterval(time.Hour))
+	if info := rec.next(t, time.Second); info.Hostname != hostname {
+		t.Fatalf("announced as %q, want %q", info.Hostname, hostname)
+	}
+
+	// WithHostname 없이도 로컬 변경이 반영되어야 함
+	n.SetLabel("role", "db")
+	if info := rec.next(t, time.Second); info.Labels["role"] != "db" {
+		t.Errorf("change announced without the new label: %v", info.Labels)
+	}
+	if m := n.Members()[hostname]; m.Labels["role"] != "db" {
+		t.Errorf("local member not updated: %+v", m)
+	}
+}
@@ -0,0 +1,118 @@
+package multicast
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func sealForTest(t *testing.T, s *Sender, body string) reassembled {
+	flags, sealed, err := s.seal([]byte(body))
+	if err != nil {
+		t.Fatalf("seal failed: %v", err)
+	}
+	return reassembled{id: newMessageID(), flags: flags, body: sealed}
+}
+
+func dropReason(err error) string {
+	var de *dropError
+	if errors.As(err, &de) {
+		return de.reason
+	}
+	return ""
+}
+
+func TestAuthenticatedMessageAccepted(t *testing.T) {
+	key := []byte("group-key")
+	n := NewNode(WithAuthentication(key, time.Minute))
+	s := &Sender{authKey: key}
+
+	body, err := n.open(sealForTest(t, s, `{"type":"hostinfo"}`))
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	if string(body) != `{"type":"hostinfo"}` {
+		t.Errorf("unexpected body: %s", body)
+	}
+}
+
+func TestAuthenticationRejections(t *testing.T) {
+	key := []byte("group-key")
+	n := NewNode(WithAuthentication(key, time.Minute))
+
+	if _, err := n.open(sealForTest(t, &Sender{}, "{}")); dropReason(err) != "rejected_unauthenticated" {
+		t.Errorf("expected unauthenticated rejection, got %v", err)
+	}
+
+	if _, err := n.open(sealForTest(t, &Sender{authKey: []byte("other-key")}, "{}")); dropReason(err) != "rejected_bad_mac" {
+		t.Errorf("expected bad mac rejection, got %v", err)
+	}
+
+	msg := sealForTest(t, &Sender{authKey: key}, "{}")
+	msg.body[authHeaderSize] ^= 0xFF
+	if _, err := n.open(msg); dropReason(err) != "rejected_bad_mac" {
+		t.Errorf("expected bad mac rejection for tampered body, got %v", err)
+	}
+}
+
+func TestReplayAndStaleRejected(t *testing.T) {
+	key := []byte("group-key")
+	n := NewNode(WithAuthentication(key, time.Minute))
+
+	msg := sealForTest(t, &Sender{authKey: key}, "{}")
+	if _, err := n.open(msg); err != nil {
+		t.Fatalf("first delivery rejected: %v", err)
+	}
+
+	if _, err := n.open(msg); dropReason(err) != "dropped_duplicate" {
+		t.Errorf("expected repeat under the same id to be a duplicate, got %v", err)
+	}
+
+	msg.id = newMessageID()
+	if _, err := n.open(msg); dropReason(err) != "rejected_replay" {
+		t.Errorf("expected replay rejection, got %v", err)
+	}
+
+	frame, _, _ := parseAuthFrame(msg.body)
+	if err := n.replay.check(frame, "late", frame.sentAt.Add(2*time.Minute)); dropReason(err) != "rejected_stale" {
+		t.Errorf("expected stale rejection, got %v", err)
+	}
+}
+
+func TestPeriodicRoundsResealed(t *testing.T) {
+	key := []byte("group-key")
+	n := NewNode(WithAuthentication(key, time.Minute))
+	s := &Sender{authKey: key}
+
+	msg, err := s.prepare(map[string]string{"type": "hostinfo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 주기 전송의 각 라운드는 새 ID 와 새 nonce 로 나가야 함
+	for round := 0; round < 3; round++ {
+		if _, err := n.open(reassembled{id: newMessageID(), flags: msg.flags, body: msg.body}); err != nil {
+			t.Fatalf("round %d rejected: %v", round, err)
+		}
+		if msg, err = s.reseal(msg.raw); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestAuthenticationConfigValidated(t *testing.T) {
+	for _, key := range [][]byte{nil, {}} {
+		if err := NewNode(WithAuthentication(key, time.Minute)).validate(); err == nil {
+			t.Errorf("empty key %v accepted", key)
+		}
+	}
+
+	for _, maxAge := range []time.Duration{0, -time.Second} {
+		n := NewNode(WithAuthentication([]byte("group-key"), maxAge))
+		if err := n.validate(); err != nil {
+			t.Fatalf("maxAge %s rejected: %v", maxAge, err)
+		}
+		if n.replay.maxAge != defaultAuthMaxAge {
+			t.Errorf("maxAge %s became %s, want %s", maxAge, n.replay.maxAge, defaultAuthMaxAge)
+		}
+	}
+}
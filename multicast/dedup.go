@@ -0,0 +1,55 @@
+package multicast
+
+import (
+	"sync"
+	"time"
+)
+
+// dedupCache remembers the IDs of messages already dispatched by any of a
+// node's receivers. Every message arrives several times, once per repeat and
+// once per interface on the same segment, but handlers should see it once.
+type dedupCache struct {
+	mu    sync.Mutex
+	seen  map[string]time.Time
+	order []dedupEntry // insertion order of seen, oldest first
+}
+
+type dedupEntry struct {
+	id string
+	at time.Time
+}
+
+func newDedupCache() *dedupCache {
+	return &dedupCache{seen: make(map[string]time.Time)}
+}
+
+// first reports whether id has not been seen within window and records it,
+// remembering at most size IDs. A zero window disables deduplication.
+func (c *dedupCache) first(id string, now time.Time, window time.Duration, size int) bool {
+	if window <= 0 {
+		return true
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for len(c.order) > 0 && (now.Sub(c.order[0].at) > window || len(c.order) >= size) {
+		c.forgetOldest()
+	}
+
+	if at, ok := c.seen[id]; ok && now.Sub(at) <= window {
+		return false
+	}
+	c.seen[id] = now
+	c.order = append(c.order, dedupEntry{id: id, at: now})
+	return true
+}
+
+func (c *dedupCache) forgetOldest() {
+	oldest := c.order[0]
+	c.order = c.order[1:]
+	// 만료 후 다시 기록된 ID 는 최신 항목을 지우지 않음
+	if c.seen[oldest.id].Equal(oldest.at) {
+		delete(c.seen, oldest.id)
+	}
+}
@@ -0,0 +1,90 @@
+package multicast
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestDedupCacheWindow(t *testing.T) {
+	c := newDedupCache()
+	now := time.Now()
+
+	if !c.first("a", now, time.Second, 100) {
+		t.Fatal("first sighting reported as duplicate")
+	}
+	if c.first("a", now.Add(500*time.Millisecond), time.Second, 100) {
+		t.Fatal("repeat within window not suppressed")
+	}
+	if !c.first("a", now.Add(2*time.Second), time.Second, 100) {
+		t.Fatal("id not forgotten after window")
+	}
+	if !c.first("b", now, 0, 100) || !c.first("b", now, 0, 100) {
+		t.Fatal("zero window should disable deduplication")
+	}
+
+	c.first("c", now.Add(10*time.Second), time.Second, 100)
+	if _, ok := c.seen["a"]; ok {
+		t.Fatal("expired id not pruned")
+	}
+}
+
+func TestDispatchDropsCopies(t *testing.T) {
+	n := NewNode()
+	calls := 0
+	n.registerHandler("ping", func(ctx context.Context, payload json.RawMessage, meta Meta) error {
+		calls++
+		return nil
+	})
+
+	msg := sealForTest(t, &Sender{}, `{"type":"ping","payload":{}}`)
+	// 반복 전송 + 두 번째 인터페이스로 들어온 사본
+	for _, iface := range []string{"eth0", "eth0", "eth1"} {
+		n.dispatch(context.Background(), msg, Meta{MessageID: msg.id, Interface: iface}, nil)
+	}
+
+	if calls != 1 {
+		t.Fatalf("handler called %d times, want 1", calls)
+	}
+	if got := n.Stats()["dropped_duplicate"]; got != 2 {
+		t.Fatalf("dropped_duplicate = %d, want 2", got)
+	}
+}
+
+func TestDedupCacheSize(t *testing.T) {
+	c := newDedupCache()
+	now := time.Now()
+	for i := 0; i < 10; i++ {
+		c.first(fmt.Sprintf("m%d", i), now, time.Minute, 4)
+	}
+	if len(c.seen) != 4 || len(c.order) != 4 {
+		t.Fatalf("remembered %d ids, want 4", len(c.seen))
+	}
+	if c.first("m9", now, time.Minute, 4) {
+		t.Error("newest id forgotten")
+	}
+	if !c.first("m0", now, time.Minute, 4) {
+		t.Error("oldest id not evicted")
+	}
+}
+
+func TestDispatchMalformedDoesNotSuppress(t *testing.T) {
+	n := NewNode()
+	calls := 0
+	n.registerHandler("ping", func(ctx context.Context, payload json.RawMessage, meta Meta) error {
+		calls++
+		return nil
+	})
+
+	// 같은 ID 의 깨진 메시지가 먼저 와도 정상 메시지는 전달되어야 함
+	bad := reassembled{id: "m1", body: []byte(`{"type":`)}
+	n.dispatch(context.Background(), bad, Meta{MessageID: "m1"}, nil)
+	good := reassembled{id: "m1", body: []byte(`{"type":"ping","payload":{}}`)}
+	n.dispatch(context.Background(), good, Meta{MessageID: "m1"}, nil)
+
+	if calls != 1 {
+		t.Fatalf("handler called %d times, want 1", calls)
+	}
+}
@@ -0,0 +1,219 @@
+package multicast
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// Forward error correction uses a systematic Reed-Solomon erasure code over
+// GF(2^8): K data shards are sent unchanged followed by P parity shards built
+// from a Cauchy matrix, and any K of the K+P shards rebuild the message.
+
+// maxFECShards is the largest K+P the GF(2^8) Cauchy construction supports.
+const maxFECShards = 255
+
+var gfExp [512]byte
+var gfLog [256]byte
+
+func init() {
+	x := 1
+	for i := 0; i < 255; i++ {
+		gfExp[i] = byte(x)
+		gfLog[x] = byte(i)
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= 0x11d
+		}
+	}
+	for i := 255; i < 512; i++ {
+		gfExp[i] = gfExp[i-255]
+	}
+}
+
+func gfMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gfExp[int(gfLog[a])+int(gfLog[b])]
+}
+
+func gfInv(a byte) byte {
+	return gfExp[255-int(gfLog[a])]
+}
+
+// cauchyRow returns the coefficients of parity shard i for k data shards.
+func cauchyRow(i, k int) []byte {
+	row := make([]byte, k)
+	for j := 0; j < k; j++ {
+		row[j] = gfInv(byte(k+i) ^ byte(j))
+	}
+	return row
+}
+
+// parityCount returns how many parity shards a redundancy ratio asks for.
+func parityCount(k int, redundancy float64) int {
+	if redundancy <= 0 {
+		return 0
+	}
+	return int(math.Ceil(float64(k) * redundancy))
+}
+
+// frameFEC prefixes data with its length and pads it into k shards of
+// shardSize, since the receiver cannot otherwise tell padding from payload.
+func frameFEC(data []byte, shardSize int) [][]byte {
+	framed := make([]byte, 4+len(data))
+	binary.BigEndian.PutUint32(framed, uint32(len(data)))
+	copy(framed[4:], data)
+
+	k := (len(framed) + shardSize - 1) / shardSize
+	shards := make([][]byte, k)
+	for i := range shards {
+		shard := make([]byte, shardSize)
+		start := i * shardSize
+		end := start + shardSize
+		if end > len(framed) {
+			end = len(framed)
+		}
+		copy(shard, framed[start:end])
+		shards[i] = shard
+	}
+	return shards
+}
+
+func unframeFEC(shards [][]byte) ([]byte, error) {
+	var framed []byte
+	for _, shard := range shards {
+		framed = append(framed, shard...)
+	}
+	if len(framed) < 4 {
+		return nil, fmt.Errorf("fec frame too short")
+	}
+	size := int(binary.BigEndian.Uint32(framed))
+	if size > len(framed)-4 {
+		return nil, fmt.Errorf("fec frame length %d exceeds %d bytes received", size, len(framed)-4)
+	}
+	return framed[4 : 4+size], nil
+}
+
+// encodeParity computes p parity shards for equally sized data shards.
+func encodeParity(data [][]byte, p int) [][]byte {
+	k := len(data)
+	parity := make([][]byte, p)
+	for i := 0; i < p; i++ {
+		row := cauchyRow(i, k)
+		out := make([]byte, len(data[0]))
+		for j, coef := range row {
+			for b, v := range data[j] {
+				out[b] ^= gfMul(coef, v)
+			}
+		}
+		parity[i] = out
+	}
+	return parity
+}
+
+// reconstructData rebuilds the k data shards from any k present entries of
+// shards (data followed by parity, nil when missing).
+func reconstructData(shards [][]byte, k int) ([][]byte, error) {
+	missing := false
+	for i := 0; i < k; i++ {
+		if shards[i] == nil {
+			missing = true
+			break
+		}
+	}
+	if !missing {
+		return shards[:k], nil
+	}
+
+	rows := make([][]byte, 0, k)
+	inputs := make([][]byte, 0, k)
+	for i, shard := range shards {
+		if shard == nil {
+			continue
+		}
+		if i < k {
+			row := make([]byte, k)
+			row[i] = 1
+			rows = append(rows, row)
+		} else {
+			rows = append(rows, cauchyRow(i-k, k))
+		}
+		inputs = append(inputs, shard)
+		if len(rows) == k {
+			break
+		}
+	}
+	if len(rows) < k {
+		return nil, fmt.Errorf("need %d shards, have %d", k, len(rows))
+	}
+
+	inv, err := invertMatrix(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	size := len(inputs[0])
+	data := make([][]byte, k)
+	for i := 0; i < k; i++ {
+		if shards[i] != nil {
+			data[i] = shards[i]
+			continue
+		}
+		out := make([]byte, size)
+		for j, coef := range inv[i] {
+			for b, v := range inputs[j] {
+				out[b] ^= gfMul(coef, v)
+			}
+		}
+		data[i] = out
+	}
+	return data, nil
+}
+
+// invertMatrix inverts a square matrix over GF(2^8) by Gauss-Jordan elimination.
+func invertMatrix(m [][]byte) ([][]byte, error) {
+	k := len(m)
+	work := make([][]byte, k)
+	for i := range m {
+		work[i] = make([]byte, 2*k)
+		copy(work[i], m[i])
+		work[i][k+i] = 1
+	}
+
+	for col := 0; col < k; col++ {
+		pivot := -1
+		for r := col; r < k; r++ {
+			if work[r][col] != 0 {
+				pivot = r
+				break
+			}
+		}
+		if pivot < 0 {
+			return nil, fmt.Errorf("fec matrix is singular")
+		}
+		work[col], work[pivot] = work[pivot], work[col]
+
+		scale := gfInv(work[col][col])
+		for c := range work[col] {
+			work[col][c] = gfMul(work[col][c], scale)
+		}
+
+		for r := 0; r < k; r++ {
+			if r == col || work[r][col] == 0 {
+				continue
+			}
+			factor := work[r][col]
+			for c := range work[r] {
+				work[r][c] ^= gfMul(factor, work[col][c])
+			}
+		}
+	}
+
+	inv := make([][]byte, k)
+	for i := range work {
+		inv[i] = work[i][k:]
+	}
+	return inv, nil
+}
@@ -0,0 +1,68 @@
+package multicast
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestFECRecoversFromAnyKFragments(t *testing.T) {
+	data := []byte(strings.Repeat("forward error correction ", 200))
+	frags, err := splitFragments("fec-id", 0, data, 256, 0.5)
+	if err != nil {
+		t.Fatalf("split failed: %v", err)
+	}
+
+	parity := frags[0].Parity
+	k := len(frags) - parity
+	if parity != (k+1)/2 {
+		t.Fatalf("expected %d parity fragments for %d data fragments, got %d", (k+1)/2, k, parity)
+	}
+
+	// Drop the first `parity` fragments, all of them data.
+	r := newReassembler(DefaultReassemblyLimits(), newCounters())
+	var msg reassembled
+	var complete bool
+	for _, frag := range frags[parity:] {
+		if msg, complete = r.add(frag, ""); complete {
+			break
+		}
+	}
+
+	if !complete {
+		t.Fatalf("message was not rebuilt from %d of %d fragments", len(frags)-parity, len(frags))
+	}
+	if !bytes.Equal(msg.body, data) {
+		t.Errorf("rebuilt data does not match original")
+	}
+}
+
+func TestFECParityInBinaryHeader(t *testing.T) {
+	f := &BinaryFragmenter{MTU: 300, Redundancy: 0.25}
+	fragments, err := f.Fragment("fec-id", 0, []byte(strings.Repeat("x", 2000)))
+	if err != nil {
+		t.Fatalf("fragment failed: %v", err)
+	}
+
+	last, err := decodeFragment(fragments[len(fragments)-1])
+	if err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if last.Parity == 0 || last.Total != len(fragments) {
+		t.Errorf("unexpected header: total=%d parity=%d, %d fragments sent", last.Total, last.Parity, len(fragments))
+	}
+}
+
+func TestFECTooFewFragments(t *testing.T) {
+	frags, err := splitFragments("fec-id", 0, []byte(strings.Repeat("y", 1000)), 100, 0.2)
+	if err != nil {
+		t.Fatalf("split failed: %v", err)
+	}
+
+	r := newReassembler(DefaultReassemblyLimits(), newCounters())
+	for _, frag := range frags[frags[0].Parity+1:] {
+		if _, complete := r.add(frag, ""); complete {
+			t.Fatalf("message completed with fewer than K fragments")
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package multicast
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// Fragment is one datagram of a message. Seq runs from 1 to Total; when
+// Parity is set, the last Parity fragments carry FEC parity instead of data.
+type Fragment struct {
+	MessageID string `json:"id"`
+	Seq       int    `json:"seq"`
+	Total     int    `json:"total"`
+	Parity    int    `json:"parity,omitempty"`
+	Flags     uint8  `json:"flags,omitempty"`
+	Data      []byte `json:"data"`
+}
+
+// Fragmenter splits a serialized message into datagrams ready for the wire.
+// flags describe how the message body was transformed and are copied into
+// every fragment header.
+type Fragmenter interface {
+	Fragment(msgID string, flags uint8, data []byte) ([][]byte, error)
+}
+
+// JSONFragmenter encodes each fragment as a JSON object with base64 data.
+// A positive Redundancy adds ceil(fragments*Redundancy) FEC parity fragments.
+// Overhead overrides the room reserved for the JSON envelope.
+type JSONFragmenter struct {
+	MTU        int
+	Redundancy float64
+	Overhead   int
+}
+
+func NewJSONFragmenter(mtu int) *JSONFragmenter {
+	return &JSONFragmenter{MTU: mtu}
+}
+
+// jsonFragmentOverhead is the room left in each datagram for the JSON
+// field names, message ID and sequence numbers.
+const jsonFragmentOverhead = 100
+
+// maxPayload accounts for base64 growing the payload by a third.
+func (f *JSONFragmenter) maxPayload() int {
+	overhead := f.Overhead
+	if overhead <= 0 {
+		overhead = jsonFragmentOverhead
+	}
+	return (f.MTU - overhead) / 4 * 3
+}
+
+func (f *JSONFragmenter) Fragment(msgID string, flags uint8, data []byte) ([][]byte, error) {
+	maxPayloadSize := f.maxPayload()
+	if maxPayloadSize <= 0 {
+		return nil, fmt.Errorf("mtu %d is too small for fragmentation", f.MTU)
+	}
+
+	frags, err := splitFragments(msgID, flags, data, maxPayloadSize, f.Redundancy)
+	if err != nil {
+		return nil, err
+	}
+
+	var fragments [][]byte
+	for _, frag := range frags {
+		j, err := json.Marshal(frag)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal fragment %d: %w", frag.Seq, err)
+		}
+		fragments = append(fragments, j)
+	}
+	return fragments, nil
+}
+
+func (f *JSONFragmenter) withRedundancy(ratio float64) Fragmenter {
+	copied := *f
+	copied.Redundancy = ratio
+	return &copied
+}
+
+// splitFragments cuts data into fragments of at most maxPayloadSize bytes and,
+// with a positive redundancy, appends Reed-Solomon parity fragments.
+func splitFragments(msgID string, flags uint8, data []byte, maxPayloadSize int, redundancy float64) ([]Fragment, error) {
+	if redundancy > 0 {
+		return splitFragmentsFEC(msgID, flags, data, maxPayloadSize, redundancy)
+	}
+
+	totalFragments := int(math.Ceil(float64(len(data)) / float64(maxPayloadSize)))
+
+	fragments := make([]Fragment, 0, totalFragments)
+	for i := 0; i < totalFragments; i++ {
+		start := i * maxPayloadSize
+		end := start + maxPayloadSize
+		if end > len(data) {
+			end = len(data)
+		}
+
+		fragments = append(fragments, Fragment{
+			MessageID: msgID,
+			Seq:       i + 1,
+			Total:     totalFragments,
+			Flags:     flags,
+			Data:      data[start:end],
+		})
+	}
+	return fragments, nil
+}
+
+func splitFragmentsFEC(msgID string, flags uint8, data []byte, maxPayloadSize int, redundancy float64) ([]Fragment, error) {
+	shards := frameFEC(data, maxPayloadSize)
+	k := len(shards)
+	p := parityCount(k, redundancy)
+	if k+p > maxFECShards {
+		return nil, fmt.Errorf("message needs %d fragments with parity, fec limit is %d", k+p, maxFECShards)
+	}
+
+	shards = append(shards, encodeParity(shards, p)...)
+	fragments := make([]Fragment, len(shards))
+	for i, shard := range shards {
+		fragments[i] = Fragment{
+			MessageID: msgID,
+			Seq:       i + 1,
+			Total:     k + p,
+			Parity:    p,
+			Flags:     flags,
+			Data:      shard,
+		}
+	}
+	return fragments, nil
+}
+
+var messageCounter uint64
+
+// newMessageID returns an ID unique to this host: hostname, send time and a
+// process-wide counter so two sends in the same nanosecond never collide.
+func newMessageID() string {
+	hostname, _ := os.Hostname()
+	seq := atomic.AddUint64(&messageCounter, 1)
+	return fmt.Sprintf("%s-%d-%d", hostname, time.Now().UnixNano(), seq)
+}
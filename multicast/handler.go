@@ -0,0 +1,83 @@
+package multicast
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"time"
+)
+
+// Meta describes where and when a message was received.
+type Meta struct {
+	MessageID string
+	Type      string
+	// CorrelationID is set on requests and replies; pass meta to Node.Reply
+	// to answer a request.
+	CorrelationID string
+	Source        *net.UDPAddr
+	Interface     string
+	Group         string
+	ReceivedAt    time.Time
+}
+
+// Validator is implemented by payload types that check themselves after decoding.
+type Validator interface {
+	Validate() error
+}
+
+// HandlerFunc is the form every registered handler is adapted to and the
+// type middleware wraps.
+type HandlerFunc func(ctx context.Context, payload json.RawMessage, meta Meta) error
+
+// Handle registers fn for msgType on node. The payload is decoded into T and,
+// if T implements Validator, validated before fn is called.
+func Handle[T any](node *Node, msgType string, fn func(ctx context.Context, msg T, meta Meta) error) {
+	node.registerHandler(msgType, func(ctx context.Context, payload json.RawMessage, meta Meta) error {
+		var msg T
+		if err := json.Unmarshal(payload, &msg); err != nil {
+			return fmt.Errorf("failed to decode %s payload: %w", msgType, err)
+		}
+		if err := validate(&msg); err != nil {
+			return fmt.Errorf("invalid %s payload: %w", msgType, err)
+		}
+		return fn(ctx, msg, meta)
+	})
+}
+
+func validate(v any) error {
+	if val, ok := v.(Validator); ok {
+		return val.Validate()
+	}
+	return nil
+}
+
+func (n *Node) RegisterHandler(msgType string, handler MessageHandler) {
+	n.registerHandler(msgType, func(ctx context.Context, payload json.RawMessage, meta Meta) error {
+		return handler(payload, meta.Group)
+	})
+}
+
+func (n *Node) registerHandler(msgType string, h HandlerFunc) {
+	n.handlersMu.Lock()
+	defer n.handlersMu.Unlock()
+	n.handlers[msgType] = h
+}
+
+// handler returns the handler for msgType wrapped in the node's middleware.
+func (n *Node) handler(msgType string) (HandlerFunc, bool) {
+	n.handlersMu.RLock()
+	defer n.handlersMu.RUnlock()
+	h, ok := n.handlers[msgType]
+	if !ok {
+		return nil, false
+	}
+	h = chain(h, n.typeMiddleware[msgType])
+	return chain(h, n.middleware), true
+}
+
+func (n *Node) handlerCount() int {
+	n.handlersMu.RLock()
+	defer n.handlersMu.RUnlock()
+	return len(n.handlers)
+}
@@ -0,0 +1,38 @@
+package multicast
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestHandleDecodesAndValidates(t *testing.T) {
+	n := NewNode()
+
+	var got HostInfoReceiver
+	var gotMeta Meta
+	Handle(n, "info", func(ctx context.Context, info HostInfoReceiver, meta Meta) error {
+		got, gotMeta = info, meta
+		return nil
+	})
+
+	h, ok := n.handler("info")
+	if !ok {
+		t.Fatalf("handler not registered")
+	}
+
+	payload, _ := json.Marshal(HostInfoReceiver{Hostname: "peer", IPs: []string{"10.0.0.1/24"}})
+	if err := h(context.Background(), payload, Meta{MessageID: "m1", Type: "info"}); err != nil {
+		t.Fatalf("handler failed: %v", err)
+	}
+	if got.Hostname != "peer" || gotMeta.MessageID != "m1" {
+		t.Errorf("unexpected message %+v meta %+v", got, gotMeta)
+	}
+
+	if err := h(context.Background(), json.RawMessage(`{"ips":[]}`), Meta{}); err == nil {
+		t.Errorf("expected validation error for missing hostname")
+	}
+	if err := h(context.Background(), json.RawMessage(`{`), Meta{}); err == nil {
+		t.Errorf("expected decode error")
+	}
+}
@@ -0,0 +1,198 @@
+package multicast
+
+import (
+	"context"
+	"log"
+	"net"
+	"sync"
+	"time"
+)
+
+// WithInterfaceWatch makes StartReceivers re-check the interfaces every
+// interval: receivers are started on interfaces that appear and stopped on
+// those that go away, and the local host is re-announced when its addresses
+// change. Zero (the default) keeps the interfaces found at startup.
+func WithInterfaceWatch(interval time.Duration) Option {
+	return func(n *Node) {
+		n.ifaceWatch = interval
+	}
+}
+
+// listener is a multicast group the node receives on.
+type listener struct {
+	group   string
+	addr    *net.UDPAddr
+	primary bool
+}
+
+// ifaceReceiver is a receiver running on one interface for one group.
+type ifaceReceiver struct {
+	name     string
+	listener listener
+	iface    net.Interface
+	cancel   context.CancelFunc
+}
+
+// Receivers that fail are restarted after minRestartBackoff, doubling with
+// every failure in a row up to maxRestartBackoff.
+const (
+	minRestartBackoff = time.Second
+	maxRestartBackoff = 5 * time.Minute
+)
+
+// restartBackoff tracks the consecutive failures of one receiver.
+type restartBackoff struct {
+	failures int
+	retryAt  time.Time
+}
+
+// interfaceSet tracks the running per-interface receivers by name.
+type interfaceSet struct {
+	mu       sync.Mutex
+	running  map[string]*ifaceReceiver
+	failures map[string]*restartBackoff
+}
+
+func newInterfaceSet() *interfaceSet {
+	return &interfaceSet{
+		running:  make(map[string]*ifaceReceiver),
+		failures: make(map[string]*restartBackoff),
+	}
+}
+
+// names returns the names of the running receivers.
+func (s *interfaceSet) names() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	names := make([]string, 0, len(s.running))
+	for name := range s.running {
+		names = append(names, name)
+	}
+	return names
+}
+
+// exited forgets r unless it has already been replaced, and schedules its
+// restart after a backoff if it failed.
+func (s *interfaceSet) exited(r *ifaceReceiver, err error, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running[r.name] == r {
+		delete(s.running, r.name)
+	}
+	if err == nil {
+		delete(s.failures, r.name)
+		return
+	}
+
+	b, ok := s.failures[r.name]
+	if !ok {
+		b = &restartBackoff{}
+		s.failures[r.name] = b
+	}
+	b.failures++
+	delay := minRestartBackoff
+	for i := 1; i < b.failures && delay < maxRestartBackoff; i++ {
+		delay *= 2
+	}
+	delay = min(delay, maxRestartBackoff)
+	b.retryAt = now.Add(delay)
+	log.Printf("Receiver on %s failed %d times in a row, retrying in %s: %v", r.name, b.failures, delay, err)
+}
+
+// wantedReceivers lists the receivers the current interfaces call for, in
+// listener and interface order.
+func (n *Node) wantedReceivers(listeners []listener) ([]*ifaceReceiver, error) {
+	var wanted []*ifaceReceiver
+	for _, l := range listeners {
+		ifaces, err := groupInterfaces(l.addr, n.ifaceFilter)
+		if err != nil {
+			return nil, err
+		}
+		for _, iface := range ifaces {
+			name := iface.Name
+			if !l.primary {
+				name += " " + l.group
+			}
+			wanted = append(wanted, &ifaceReceiver{name: name, listener: l, iface: iface})
+		}
+	}
+	return wanted, nil
+}
+
+// reconcile stops receivers whose interface is gone (or was re-created with a
+// new index) and starts receivers for wanted ones that are not running.
+// A receiver that failed on its own is restarted once its backoff has passed.
+func (n *Node) reconcile(ctx context.Context, receivers *Receivers, pool *workerPool, wanted []*ifaceReceiver) {
+	byName := make(map[string]*ifaceReceiver, len(wanted))
+	for _, w := range wanted {
+		byName[w.name] = w
+	}
+
+	set := receivers.ifaces
+	set.mu.Lock()
+	defer set.mu.Unlock()
+
+	for name := range set.failures {
+		if _, ok := byName[name]; !ok {
+			delete(set.failures, name)
+		}
+	}
+	for name, r := range set.running {
+		if w, ok := byName[name]; ok && w.iface.Index == r.iface.Index {
+			continue
+		}
+		log.Printf("🔌 Stopping receiver on interface: %s for %s", r.iface.Name, r.listener.group)
+		r.cancel()
+		delete(set.running, name)
+	}
+
+	now := time.Now()
+	for _, w := range wanted {
+		if _, ok := set.running[w.name]; ok {
+			continue
+		}
+		if b, ok := set.failures[w.name]; ok && now.Before(b.retryAt) {
+			continue
+		}
+		log.Printf("Starting receiver on interface: %s [%s] for %s", w.iface.Name, w.iface.HardwareAddr, w.listener.group)
+		r := w
+		rctx, cancel := context.WithCancel(ctx)
+		r.cancel = cancel
+		set.running[r.name] = r
+		receivers.run(r.name, func() error {
+			defer cancel()
+			err := n.receive(rctx, r.listener.addr, &r.iface, r.listener.group, pool)
+			set.exited(r, err, time.Now())
+			return err
+		})
+	}
+}
+
+// watchInterfaces reconciles the receivers with the interfaces every
+// n.ifaceWatch and re-announces the local host when its addresses change.
+func (n *Node) watchInterfaces(ctx context.Context, receivers *Receivers, pool *workerPool, listeners []listener) error {
+	ticker := time.NewTicker(n.ifaceWatch)
+	defer ticker.Stop()
+
+	ips := n.localAddrs()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		wanted, err := n.wantedReceivers(listeners)
+		if err != nil {
+			log.Printf("Failed to list interfaces: %v", err)
+		} else {
+			n.reconcile(ctx, receivers, pool, wanted)
+		}
+
+		if current := n.localAddrs(); !equalIPs(ips, current) {
+			log.Printf("🔄 Local addresses changed: %v", current)
+			ips = current
+			n.localInfoChanged()
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package multicast
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func waitInterfaces(t *testing.T, r *Receivers, want []string) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		got := r.Interfaces()
+		if len(got) == 0 && len(want) == 0 || reflect.DeepEqual(got, want) {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("receivers on %v, want %v", got, want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestReconcileStartsAndStopsReceivers(t *testing.T) {
+	group := "239.0.0.77:9977"
+	addr, err := net.ResolveUDPAddr("udp", group)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := NewNode()
+	listeners := []listener{{group: group, addr: addr, primary: true}}
+	wanted, err := n.wantedReceivers(listeners)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wanted) == 0 {
+		t.Skip("no multicast interface available")
+	}
+	wanted = wanted[:1]
+	name := wanted[0].name
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := newReceivers(cancel, 4)
+	pool := newWorkerPool(DefaultDispatchConfig(), n.stats)
+
+	n.reconcile(ctx, r, pool, wanted)
+	waitInterfaces(t, r, []string{name})
+
+	// 같은 이름이라도 index 가 바뀌면 다시 시작
+	r.ifaces.mu.Lock()
+	old := r.ifaces.running[name]
+	r.ifaces.mu.Unlock()
+	renewed := *wanted[0]
+	renewed.iface.Index += 1000
+	n.reconcile(ctx, r, pool, []*ifaceReceiver{&renewed})
+	r.ifaces.mu.Lock()
+	current := r.ifaces.running[name]
+	r.ifaces.mu.Unlock()
+	if current == old {
+		t.Fatal("receiver on re-created interface was not restarted")
+	}
+
+	n.reconcile(ctx, r, pool, nil)
+	waitInterfaces(t, r, nil)
+
+	if err := r.Stop(); err != nil {
+		var rerr *ReceiverError
+		if !errors.As(err, &rerr) || rerr.Interface != name {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
+
+func TestReconcileRestartsFailedReceiver(t *testing.T) {
+	group := "239.0.0.77:9977"
+	addr, err := net.ResolveUDPAddr("udp", group)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := NewNode()
+	gone := &ifaceReceiver{
+		name:     "gone0",
+		listener: listener{group: group, addr: addr, primary: true},
+		iface:    net.Interface{Index: 99999, Name: "gone0", Flags: net.FlagUp | net.FlagMulticast},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := newReceivers(cancel, 4)
+	pool := newWorkerPool(DefaultDispatchConfig(), n.stats)
+
+	n.reconcile(ctx, r, pool, []*ifaceReceiver{gone})
+	select {
+	case err := <-r.Errors():
+		var rerr *ReceiverError
+		if !errors.As(err, &rerr) || rerr.Interface != "gone0" {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("receiver on missing interface did not fail")
+	}
+	waitInterfaces(t, r, nil)
+
+	// 대기 시간 동안은 재시작하지 않음
+	retry := *gone
+	n.reconcile(ctx, r, pool, []*ifaceReceiver{&retry})
+	if names := r.ifaces.names(); len(names) != 0 {
+		t.Fatalf("failed receiver restarted before its backoff: %v", names)
+	}
+
+	// 대기 시간이 지나면 다시 시도하고 대기 시간은 두 배로
+	r.ifaces.mu.Lock()
+	r.ifaces.failures["gone0"].retryAt = time.Time{}
+	r.ifaces.mu.Unlock()
+	retry = *gone
+	n.reconcile(ctx, r, pool, []*ifaceReceiver{&retry})
+	select {
+	case <-r.Errors():
+	case <-time.After(2 * time.Second):
+		t.Fatal("failed receiver was not restarted")
+	}
+	waitInterfaces(t, r, nil)
+	r.ifaces.mu.Lock()
+	b := *r.ifaces.failures["gone0"]
+	r.ifaces.mu.Unlock()
+	if b.failures != 2 || time.Until(b.retryAt) <= minRestartBackoff {
+		t.Errorf("backoff after two failures: %d, retry in %s", b.failures, time.Until(b.retryAt))
+	}
+
+	if err := r.Stop(); err == nil || len(r.errs) != 1 {
+		t.Errorf("Stop returned %v, want the latest failure only", err)
+	}
+}
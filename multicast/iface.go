@@ -0,0 +1,199 @@
+package multicast
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"strings"
+
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+// multicastInterfaces returns every interface that is up and multicast capable.
+func multicastInterfaces() ([]net.Interface, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list interfaces: %w", err)
+	}
+
+	var result []net.Interface
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagMulticast == 0 {
+			continue
+		}
+		result = append(result, iface)
+	}
+	return result, nil
+}
+
+// groupInterfaces returns the multicast interfaces usable for group: those
+// with an address of the group's family, narrowed to the zone if one is given
+// (e.g. [ff02::1%eth0]:9999) and to the interfaces filter selects.
+func groupInterfaces(group *net.UDPAddr, filter *InterfaceFilter) ([]net.Interface, error) {
+	ifaces, err := multicastInterfaces()
+	if err != nil {
+		return nil, err
+	}
+	sel, err := filter.selector()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []net.Interface
+	for _, iface := range ifaces {
+		if group.Zone != "" && iface.Name != group.Zone {
+			continue
+		}
+		if !sel.allows(iface) {
+			continue
+		}
+		if !hasFamily(iface, isIPv6Group(group)) {
+			continue
+		}
+		result = append(result, iface)
+	}
+	return result, nil
+}
+
+func isIPv6Group(group *net.UDPAddr) bool {
+	return group.IP.To4() == nil
+}
+
+func hasFamily(iface net.Interface, ipv6 bool) bool {
+	addrs, _ := iface.Addrs()
+	for _, addr := range addrs {
+		if ipnet, ok := addr.(*net.IPNet); ok && (ipnet.IP.To4() == nil) == ipv6 {
+			return true
+		}
+	}
+	return false
+}
+
+func listenNetwork(group *net.UDPAddr) string {
+	if isIPv6Group(group) {
+		return "udp6"
+	}
+	return "udp4"
+}
+
+// sendSocketOptions are the socket settings of a sending connection.
+type sendSocketOptions struct {
+	hopLimit   int
+	loopback   bool
+	sendBuffer int
+}
+
+// openSendConn opens a UDP socket that sends multicast to group through iface.
+// hopLimit sets the multicast TTL (IPv4) or hop limit (IPv6) when positive.
+func openSendConn(iface net.Interface, group *net.UDPAddr, opts sendSocketOptions) (net.PacketConn, error) {
+	conn, err := net.ListenPacket(listenNetwork(group), "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create UDP socket: %w", err)
+	}
+	if opts.sendBuffer > 0 {
+		if err := conn.(*net.UDPConn).SetWriteBuffer(opts.sendBuffer); err != nil {
+			log.Printf("Warning: failed to set write buffer: %v", err)
+		}
+	}
+
+	if isIPv6Group(group) {
+		p := ipv6.NewPacketConn(conn)
+		if err := p.SetMulticastInterface(&iface); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to set multicast interface: %w", err)
+		}
+		if opts.hopLimit > 0 {
+			if err := p.SetMulticastHopLimit(opts.hopLimit); err != nil {
+				conn.Close()
+				return nil, fmt.Errorf("failed to set multicast hop limit: %w", err)
+			}
+		}
+		if err := p.SetMulticastLoopback(opts.loopback); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to set multicast loopback: %w", err)
+		}
+		return conn, nil
+	}
+
+	p := ipv4.NewPacketConn(conn)
+	if err := p.SetMulticastInterface(&iface); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to set multicast interface: %w", err)
+	}
+	if opts.hopLimit > 0 {
+		if err := p.SetMulticastTTL(opts.hopLimit); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to set multicast TTL: %w", err)
+		}
+	}
+	if err := p.SetMulticastLoopback(opts.loopback); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to set multicast loopback: %w", err)
+	}
+	return conn, nil
+}
+
+// destination returns the address to write to on iface. Link-local and
+// interface-local IPv6 groups are scoped, so they need the interface as zone.
+func destination(group *net.UDPAddr, iface net.Interface) *net.UDPAddr {
+	if !isIPv6Group(group) || !(group.IP.IsLinkLocalMulticast() || group.IP.IsInterfaceLocalMulticast()) {
+		return group
+	}
+	dst := *group
+	dst.Zone = iface.Name
+	return &dst
+}
+
+func getLocalIPs() []string {
+	return localIPs(true, nil)
+}
+
+// localIPs returns the non-loopback addresses of this host for both families,
+// limited to what filter selects. IPv6 link-local addresses carry their zone,
+// e.g. fe80::1%eth0/64, since they are meaningless without it.
+func localIPs(withPrefix bool, filter *InterfaceFilter) []string {
+	var ips []string
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		log.Printf("Failed to get interface addresses: %v", err)
+		return ips
+	}
+	sel, err := filter.selector()
+	if err != nil {
+		log.Printf("Failed to apply interface filter: %v", err)
+		return ips
+	}
+
+	for _, iface := range ifaces {
+		if !sel.allows(iface) {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			ipnet, ok := addr.(*net.IPNet)
+			if !ok || ipnet.IP.IsLoopback() || !sel.allowsIP(ipnet.IP) {
+				continue
+			}
+			ips = append(ips, formatIP(ipnet, iface.Name, withPrefix))
+		}
+	}
+
+	return ips
+}
+
+func formatIP(ipnet *net.IPNet, zone string, withPrefix bool) string {
+	var b strings.Builder
+	b.WriteString(ipnet.IP.String())
+	if ipnet.IP.To4() == nil && ipnet.IP.IsLinkLocalUnicast() {
+		b.WriteString("%" + zone)
+	}
+	if withPrefix {
+		ones, _ := ipnet.Mask.Size()
+		fmt.Fprintf(&b, "/%d", ones)
+	}
+	return b.String()
+}
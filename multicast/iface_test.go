@@ -0,0 +1,102 @@
+package multicast
+
+import (
+	"net"
+	"testing"
+)
+
+func TestDestination(t *testing.T) {
+	eth0 := net.Interface{Name: "eth0"}
+	tests := []struct {
+		group string
+		want  string
+	}{
+		{"239.0.0.1:9999", "239.0.0.1:9999"},
+		{"[ff05::1]:9999", "[ff05::1]:9999"},
+		{"[ff02::1]:9999", "[ff02::1%eth0]:9999"},
+		{"[ff01::1]:9999", "[ff01::1%eth0]:9999"},
+		{"[ff02::1%eth1]:9999", "[ff02::1%eth0]:9999"},
+	}
+	for _, tt := range tests {
+		group, err := net.ResolveUDPAddr("udp", tt.group)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.group, err)
+		}
+		if got := destination(group, eth0).String(); got != tt.want {
+			t.Errorf("destination(%s) = %s, want %s", tt.group, got, tt.want)
+		}
+	}
+}
+
+func TestFormatIP(t *testing.T) {
+	tests := []struct {
+		cidr       string
+		withPrefix bool
+		want       string
+	}{
+		{"192.0.2.10/24", true, "192.0.2.10/24"},
+		{"192.0.2.10/24", false, "192.0.2.10"},
+		{"2001:db8::1/64", true, "2001:db8::1/64"},
+		{"fe80::1/64", true, "fe80::1%eth0/64"},
+		{"fe80::1/64", false, "fe80::1%eth0"},
+	}
+	for _, tt := range tests {
+		ip, ipnet, err := net.ParseCIDR(tt.cidr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ipnet.IP = ip
+		if got := formatIP(ipnet, "eth0", tt.withPrefix); got != tt.want {
+			t.Errorf("formatIP(%s, %v) = %s, want %s", tt.cidr, tt.withPrefix, got, tt.want)
+		}
+	}
+}
+
+func TestListenNetwork(t *testing.T) {
+	for group, want := range map[string]string{
+		"239.0.0.1:9999":      "udp4",
+		"[ff02::1]:9999":      "udp6",
+		"[ff02::1%eth0]:9999": "udp6",
+	} {
+		addr, _ := net.ResolveUDPAddr("udp", group)
+		if got := listenNetwork(addr); got != want {
+			t.Errorf("listenNetwork(%s) = %s, want %s", group, got, want)
+		}
+	}
+}
+
+func TestGroupInterfacesFamilyAndZone(t *testing.T) {
+	v4, _ := net.ResolveUDPAddr("udp", "239.0.0.1:9999")
+	v6, _ := net.ResolveUDPAddr("udp", "[ff02::1]:9999")
+
+	for _, group := range []*net.UDPAddr{v4, v6} {
+		ifaces, err := groupInterfaces(group, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, iface := range ifaces {
+			if !hasFamily(iface, isIPv6Group(group)) {
+				t.Errorf("%s selected for %s without an address of its family", iface.Name, group)
+			}
+		}
+	}
+
+	ifaces, _ := groupInterfaces(v6, nil)
+	if len(ifaces) == 0 {
+		t.Skip("no IPv6 multicast interface available")
+	}
+	zoned := *v6
+	zoned.Zone = ifaces[0].Name
+	got, err := groupInterfaces(&zoned, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Name != ifaces[0].Name {
+		t.Errorf("zone %s selected %v", zoned.Zone, got)
+	}
+
+	zoned.Zone = "no-such-iface"
+	if got, _ := groupInterfaces(&zoned, nil); len(got) != 0 {
+		t.Errorf("unknown zone selected %v", got)
+	}
+}
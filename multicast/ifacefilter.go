@@ -0,0 +1,195 @@
+package multicast
+
+import (
+	"fmt"
+	"net"
+	"path"
+)
+
+// InterfaceFilter selects the interfaces a node sends and receives on and
+// whose addresses it announces. An interface is used when it
+//   - matches one of Include (name globs, e.g. "eth*") if any are given,
+//   - matches none of Exclude (e.g. "docker*", "veth*", "tun*"),
+//   - has an address in one of IncludeCIDRs if any are given, and none in
+//     ExcludeCIDRs,
+//   - has all RequireFlags and none of ExcludeFlags (e.g. net.FlagPointToPoint),
+//   - owns the default route, if DefaultRoute is set.
+//
+// The CIDR rules also narrow the announced addresses themselves.
+type InterfaceFilter struct {
+	Include      []string
+	Exclude      []string
+	IncludeCIDRs []string
+	ExcludeCIDRs []string
+	RequireFlags net.Flags
+	ExcludeFlags net.Flags
+	DefaultRoute bool
+}
+
+// WithInterfaceFilter restricts the node's receivers, senders and announced
+// addresses to the interfaces f selects.
+func WithInterfaceFilter(f InterfaceFilter) Option {
+	return func(n *Node) {
+		n.ifaceFilter = &f
+	}
+}
+
+// WithInterfaces restricts the sender to the interfaces f selects.
+func WithInterfaces(f InterfaceFilter) SenderOption {
+	return func(s *Sender) {
+		s.ifaceFilter = &f
+	}
+}
+
+func (f InterfaceFilter) Validate() error {
+	for _, pattern := range append(append([]string{}, f.Include...), f.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid interface pattern %q: %w", pattern, err)
+		}
+	}
+	if _, err := parseCIDRs(f.IncludeCIDRs); err != nil {
+		return err
+	}
+	if _, err := parseCIDRs(f.ExcludeCIDRs); err != nil {
+		return err
+	}
+	return nil
+}
+
+func parseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, ipnet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cidr %q: %w", cidr, err)
+		}
+		nets = append(nets, ipnet)
+	}
+	return nets, nil
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	for _, ipnet := range nets {
+		if ipnet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// interfaceSelector is an InterfaceFilter prepared for matching.
+type interfaceSelector struct {
+	filter       InterfaceFilter
+	include      []*net.IPNet
+	exclude      []*net.IPNet
+	defaultRoute string
+}
+
+// selector prepares f for matching. A nil filter selects everything.
+func (f *InterfaceFilter) selector() (*interfaceSelector, error) {
+	if f == nil {
+		return nil, nil
+	}
+	include, err := parseCIDRs(f.IncludeCIDRs)
+	if err != nil {
+		return nil, err
+	}
+	exclude, err := parseCIDRs(f.ExcludeCIDRs)
+	if err != nil {
+		return nil, err
+	}
+	s := &interfaceSelector{filter: *f, include: include, exclude: exclude}
+	if f.DefaultRoute {
+		if s.defaultRoute, err = defaultRouteInterface(); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// allows reports whether iface passes the name, flag and route rules.
+func (s *interfaceSelector) allows(iface net.Interface) bool {
+	if s == nil {
+		return true
+	}
+	f := s.filter
+	if len(f.Include) > 0 && !matchAny(f.Include, iface.Name) {
+		return false
+	}
+	if matchAny(f.Exclude, iface.Name) {
+		return false
+	}
+	if iface.Flags&f.RequireFlags != f.RequireFlags || iface.Flags&f.ExcludeFlags != 0 {
+		return false
+	}
+	if s.defaultRoute != "" && iface.Name != s.defaultRoute {
+		return false
+	}
+	if len(s.include) == 0 && len(s.exclude) == 0 {
+		return true
+	}
+
+	addrs, _ := iface.Addrs()
+	included := len(s.include) == 0
+	for _, addr := range addrs {
+		ipnet, ok := addr.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		if containsIP(s.exclude, ipnet.IP) {
+			return false
+		}
+		if containsIP(s.include, ipnet.IP) {
+			included = true
+		}
+	}
+	return included
+}
+
+// allowsIP reports whether ip passes the CIDR rules.
+func (s *interfaceSelector) allowsIP(ip net.IP) bool {
+	if s == nil {
+		return true
+	}
+	if containsIP(s.exclude, ip) {
+		return false
+	}
+	return len(s.include) == 0 || containsIP(s.include, ip)
+}
+
+// defaultRouteInterface returns the name of the interface the OS would use
+// to reach the internet. Connecting a UDP socket sends nothing; it only
+// resolves the route and thereby the local address.
+func defaultRouteInterface() (string, error) {
+	for _, probe := range []string{"8.8.8.8:53", "[2001:4860:4860::8888]:53"} {
+		conn, err := net.Dial("udp", probe)
+		if err != nil {
+			continue
+		}
+		local := conn.LocalAddr().(*net.UDPAddr).IP
+		conn.Close()
+
+		ifaces, err := net.Interfaces()
+		if err != nil {
+			return "", fmt.Errorf("failed to list interfaces: %w", err)
+		}
+		for _, iface := range ifaces {
+			addrs, _ := iface.Addrs()
+			for _, addr := range addrs {
+				if ipnet, ok := addr.(*net.IPNet); ok && ipnet.IP.Equal(local) {
+					return iface.Name, nil
+				}
+			}
+		}
+	}
+	return "", fmt.Errorf("no interface owns a default route")
+}
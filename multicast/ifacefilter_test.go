@@ -0,0 +1,64 @@
+package multicast
+
+import (
+	"net"
+	"testing"
+)
+
+func TestInterfaceSelector(t *testing.T) {
+	eth := net.Interface{Name: "eth0", Flags: net.FlagUp | net.FlagMulticast}
+	docker := net.Interface{Name: "docker0", Flags: net.FlagUp | net.FlagMulticast}
+	tun := net.Interface{Name: "tun0", Flags: net.FlagUp | net.FlagMulticast | net.FlagPointToPoint}
+
+	tests := []struct {
+		name   string
+		filter InterfaceFilter
+		want   []bool // eth, docker, tun
+	}{
+		{"empty", InterfaceFilter{}, []bool{true, true, true}},
+		{"include", InterfaceFilter{Include: []string{"eth*"}}, []bool{true, false, false}},
+		{"exclude", InterfaceFilter{Exclude: []string{"docker*", "veth*"}}, []bool{true, false, true}},
+		{"flags", InterfaceFilter{ExcludeFlags: net.FlagPointToPoint}, []bool{true, true, false}},
+		{"require", InterfaceFilter{RequireFlags: net.FlagPointToPoint}, []bool{false, false, true}},
+	}
+	for _, tt := range tests {
+		sel, err := tt.filter.selector()
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		for i, iface := range []net.Interface{eth, docker, tun} {
+			if got := sel.allows(iface); got != tt.want[i] {
+				t.Errorf("%s: allows(%s) = %v, want %v", tt.name, iface.Name, got, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestInterfaceSelectorCIDRs(t *testing.T) {
+	f := &InterfaceFilter{IncludeCIDRs: []string{"10.0.0.0/8"}, ExcludeCIDRs: []string{"10.99.0.0/16"}}
+	sel, err := f.selector()
+	if err != nil {
+		t.Fatalf("selector failed: %v", err)
+	}
+	for ip, want := range map[string]bool{"10.1.2.3": true, "10.99.0.1": false, "192.168.1.1": false} {
+		if got := sel.allowsIP(net.ParseIP(ip)); got != want {
+			t.Errorf("allowsIP(%s) = %v, want %v", ip, got, want)
+		}
+	}
+
+	if err := (InterfaceFilter{IncludeCIDRs: []string{"10.0.0.0"}}).Validate(); err == nil {
+		t.Errorf("expected invalid cidr to be rejected")
+	}
+	if err := (InterfaceFilter{Include: []string{"eth["}}).Validate(); err == nil {
+		t.Errorf("expected invalid pattern to be rejected")
+	}
+	if _, err := NewNode(WithInterfaceFilter(InterfaceFilter{Exclude: []string{"["}})).NewSender("239.0.0.1:9999"); err == nil {
+		t.Errorf("sender created with invalid interface filter")
+	}
+}
+
+func TestLocalIPsFiltered(t *testing.T) {
+	if ips := localIPs(true, &InterfaceFilter{Include: []string{"no-such-interface"}}); len(ips) != 0 {
+		t.Errorf("expected no addresses, got %v", ips)
+	}
+}
@@ -0,0 +1,95 @@
+package multicast
+
+import (
+	"log"
+	"maps"
+	"time"
+)
+
+func SetLocalInfo(info HostInfoReceiver) {
+	defaultNode.SetLocalInfo(info)
+}
+
+func SetLabel(key, value string) {
+	defaultNode.SetLabel(key, value)
+}
+
+// SetLocalInfo sets the Version, BuildDate, Revision, Endpoint, EndpointPort
+// and Labels this node announces. Hostname and IPs are ignored; they are
+// always filled in from the host. A change is re-announced right away.
+func (n *Node) SetLocalInfo(info HostInfoReceiver) {
+	if n.setLocalInfo(info) {
+		n.localInfoChanged()
+	}
+}
+
+func (n *Node) setLocalInfo(info HostInfoReceiver) bool {
+	info.Hostname = ""
+	info.IPs = nil
+	info.Labels = maps.Clone(info.Labels)
+
+	n.hostDataLock.Lock()
+	defer n.hostDataLock.Unlock()
+	if sameHostInfo(n.localInfo, info) {
+		return false
+	}
+	n.localInfo = info
+	return true
+}
+
+// SetLabel sets a single label of the local host. An empty value removes it.
+func (n *Node) SetLabel(key, value string) {
+	n.hostDataLock.Lock()
+	current, ok := n.localInfo.Labels[key]
+	if (value == "" && !ok) || (ok && current == value) {
+		n.hostDataLock.Unlock()
+		return
+	}
+	labels := maps.Clone(n.localInfo.Labels)
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+	if value == "" {
+		delete(labels, key)
+	} else {
+		labels[key] = value
+	}
+	n.localInfo.Labels = labels
+	n.hostDataLock.Unlock()
+
+	n.localInfoChanged()
+}
+
+// hostInfo returns the local host info as announced right now.
+func (n *Node) hostInfo(hostname string) HostInfoReceiver {
+	n.hostDataLock.RLock()
+	info := n.localInfo
+	n.hostDataLock.RUnlock()
+
+	info.Hostname = hostname
+	info.IPs = n.localAddrs()
+	return info
+}
+
+// localInfoChanged refreshes the local membership entry and re-announces it,
+// through a running announcer if there is one.
+func (n *Node) localInfoChanged() {
+	if n.hostname == "" {
+		return
+	}
+	info := n.hostInfo(n.hostname)
+	n.observe(info, time.Now())
+
+	if n.announcers.Load() > 0 {
+		select {
+		case n.localChanged <- struct{}{}:
+		default:
+		}
+		return
+	}
+	if group := n.Group(); group != "" {
+		if err := n.SendWithEnvelope(group, "hostinfo", info); err != nil {
+			log.Printf("Failed to announce host info: %v", err)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package multicast
+
+import "testing"
+
+func TestSetLocalInfoUpdatesMembership(t *testing.T) {
+	n := NewNode(WithHostname("local"))
+	n.Init()
+	events, cancel := n.Subscribe(8)
+	defer cancel()
+
+	n.SetLocalInfo(HostInfoReceiver{Version: "1.0", Endpoint: "api", EndpointPort: 8080})
+	n.SetLabel("role", "db")
+	n.SetLabel("role", "db")
+
+	local := n.Members()["local"]
+	if local.Version != "1.0" || local.EndpointPort != 8080 || local.Labels["role"] != "db" {
+		t.Fatalf("unexpected local info %+v", local)
+	}
+	if len(local.IPs) != len(getLocalIPs()) {
+		t.Errorf("local IPs not filled in: %v", local.IPs)
+	}
+	if len(events) != 2 {
+		t.Errorf("expected 2 update events, got %d", len(events))
+	}
+
+	n.SetLabel("role", "")
+	if _, ok := n.Members()["local"].Labels["role"]; ok {
+		t.Errorf("label not removed")
+	}
+}
@@ -0,0 +1,191 @@
+package multicast
+
+import (
+	"context"
+	"log"
+	"maps"
+	"sync"
+	"time"
+)
+
+// Member is a host in the node's membership table.
+type Member struct {
+	HostInfoReceiver
+	FirstSeen time.Time `json:"firstSeen"`
+	LastSeen  time.Time `json:"lastSeen"`
+	// Stale marks an entry restored from a snapshot that the host has not
+	// confirmed since.
+	Stale bool `json:"stale,omitempty"`
+}
+
+type MembershipEventType int
+
+const (
+	MemberJoined MembershipEventType = iota
+	MemberUpdated
+	MemberLeft
+)
+
+func (t MembershipEventType) String() string {
+	switch t {
+	case MemberJoined:
+		return "join"
+	case MemberUpdated:
+		return "update"
+	case MemberLeft:
+		return "leave"
+	default:
+		return "unknown"
+	}
+}
+
+// MembershipEvent reports a host appearing, changing or disappearing.
+type MembershipEvent struct {
+	Type   MembershipEventType
+	Member Member
+}
+
+// minMemberTTL is the shortest member TTL StartReceivers accepts; expiry
+// runs every ttl/2.
+const minMemberTTL = time.Millisecond
+
+// WithMemberTTL expires hosts that have not been heard from for ttl, which
+// must be at least a millisecond. The local host never expires. Without it
+// entries are kept forever.
+func WithMemberTTL(ttl time.Duration) Option {
+	return func(n *Node) {
+		n.memberTTL = ttl
+	}
+}
+
+type subscription struct {
+	ch chan MembershipEvent
+}
+
+// subscribers fans membership events out to subscriptions. Slow subscribers
+// lose events rather than blocking message handling.
+type subscribers struct {
+	mu   sync.Mutex
+	subs map[*subscription]struct{}
+}
+
+func (s *subscribers) publish(ev MembershipEvent, stats *counters) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for sub := range s.subs {
+		select {
+		case sub.ch <- ev:
+		default:
+			stats.inc("dropped_membership_event")
+		}
+	}
+}
+
+func Subscribe(buffer int) (<-chan MembershipEvent, func()) {
+	return defaultNode.Subscribe(buffer)
+}
+
+// Subscribe returns a channel of membership events and a function that ends
+// the subscription and closes the channel.
+func (n *Node) Subscribe(buffer int) (<-chan MembershipEvent, func()) {
+	sub := &subscription{ch: make(chan MembershipEvent, buffer)}
+
+	n.subscribers.mu.Lock()
+	n.subscribers.subs[sub] = struct{}{}
+	n.subscribers.mu.Unlock()
+
+	var once sync.Once
+	return sub.ch, func() {
+		once.Do(func() {
+			n.subscribers.mu.Lock()
+			delete(n.subscribers.subs, sub)
+			n.subscribers.mu.Unlock()
+			close(sub.ch)
+		})
+	}
+}
+
+func Members() map[string]Member {
+	return defaultNode.Members()
+}
+
+// Members returns a copy of the membership table keyed by hostname.
+func (n *Node) Members() map[string]Member {
+	n.hostDataLock.RLock()
+	defer n.hostDataLock.RUnlock()
+	copied := make(map[string]Member, len(n.hostData))
+	for k, v := range n.hostData {
+		copied[k] = v
+	}
+	return copied
+}
+
+// observe records info as seen at now and reports whether it was new or changed.
+func (n *Node) observe(info HostInfoReceiver, now time.Time) bool {
+	n.hostDataLock.Lock()
+	defer n.hostDataLock.Unlock()
+
+	existing, found := n.hostData[info.Hostname]
+	if !found {
+		m := Member{HostInfoReceiver: info, FirstSeen: now, LastSeen: now}
+		n.hostData[info.Hostname] = m
+		n.subscribers.publish(MembershipEvent{Type: MemberJoined, Member: m}, n.stats)
+		return true
+	}
+
+	existing.LastSeen = now
+	changed := existing.Stale || !sameHostInfo(existing.HostInfoReceiver, info)
+	if changed {
+		existing.HostInfoReceiver = info
+		existing.Stale = false
+	}
+	n.hostData[info.Hostname] = existing
+	if changed {
+		n.subscribers.publish(MembershipEvent{Type: MemberUpdated, Member: existing}, n.stats)
+	}
+	return changed
+}
+
+func sameHostInfo(a, b HostInfoReceiver) bool {
+	return equalIPs(a.IPs, b.IPs) &&
+		a.Endpoint == b.Endpoint &&
+		a.EndpointPort == b.EndpointPort &&
+		a.Version == b.Version &&
+		a.BuildDate == b.BuildDate &&
+		a.Revision == b.Revision &&
+		maps.Equal(a.Labels, b.Labels)
+}
+
+// expireMembers removes every remote host not seen since now-ttl.
+func (n *Node) expireMembers(now time.Time) {
+	n.hostDataLock.Lock()
+	defer n.hostDataLock.Unlock()
+
+	for hostname, m := range n.hostData {
+		seen := m.LastSeen
+		if m.Stale && n.restoredAt.After(seen) {
+			// 복원된 항목은 복원 시점부터 TTL 적용
+			seen = n.restoredAt
+		}
+		if hostname == n.hostname || now.Sub(seen) <= n.memberTTL {
+			continue
+		}
+		delete(n.hostData, hostname)
+		log.Printf("👋 Host %s expired (last seen %s)", hostname, m.LastSeen.Format(time.RFC3339))
+		n.subscribers.publish(MembershipEvent{Type: MemberLeft, Member: m}, n.stats)
+	}
+}
+
+func (n *Node) runMemberExpiry(ctx context.Context) error {
+	ticker := time.NewTicker(n.memberTTL / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case now := <-ticker.C:
+			n.expireMembers(now)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package multicast
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMembershipEvents(t *testing.T) {
+	n := NewNode(WithHostname("local"), WithMemberTTL(time.Minute))
+	events, cancel := n.Subscribe(8)
+	defer cancel()
+
+	start := time.Now()
+	n.observe(HostInfoReceiver{Hostname: "local"}, start)
+	n.observe(HostInfoReceiver{Hostname: "peer", IPs: []string{"10.0.0.1/24"}}, start)
+	n.observe(HostInfoReceiver{Hostname: "peer", IPs: []string{"10.0.0.1/24"}}, start.Add(time.Second))
+	n.observe(HostInfoReceiver{Hostname: "peer", IPs: []string{"10.0.0.2/24"}}, start.Add(2*time.Second))
+	n.expireMembers(start.Add(2 * time.Minute))
+
+	want := []struct {
+		typ      MembershipEventType
+		hostname string
+	}{
+		{MemberJoined, "local"},
+		{MemberJoined, "peer"},
+		{MemberUpdated, "peer"},
+		{MemberLeft, "peer"},
+	}
+	for _, w := range want {
+		select {
+		case ev := <-events:
+			if ev.Type != w.typ || ev.Member.Hostname != w.hostname {
+				t.Fatalf("expected %s %s, got %s %s", w.typ, w.hostname, ev.Type, ev.Member.Hostname)
+			}
+		default:
+			t.Fatalf("missing %s event for %s", w.typ, w.hostname)
+		}
+	}
+
+	members := n.Members()
+	if _, ok := members["peer"]; ok {
+		t.Errorf("expired peer still in membership table")
+	}
+	if _, ok := members["local"]; !ok {
+		t.Errorf("local host must never expire")
+	}
+}
+
+func TestMemberLastSeen(t *testing.T) {
+	n := NewNode(WithMemberTTL(time.Minute))
+	start := time.Now()
+	n.observe(HostInfoReceiver{Hostname: "peer"}, start)
+	n.observe(HostInfoReceiver{Hostname: "peer"}, start.Add(50*time.Second))
+	n.expireMembers(start.Add(90 * time.Second))
+
+	m, ok := n.Members()["peer"]
+	if !ok {
+		t.Fatalf("peer expired although it was seen within the ttl")
+	}
+	if !m.FirstSeen.Equal(start) || !m.LastSeen.Equal(start.Add(50*time.Second)) {
+		t.Errorf("unexpected timestamps %v %v", m.FirstSeen, m.LastSeen)
+	}
+}
+
+func TestMemberTTLValidated(t *testing.T) {
+	for _, ttl := range []time.Duration{-time.Second, 1, time.Microsecond} {
+		if err := NewNode(WithMemberTTL(ttl)).validate(); err == nil {
+			t.Errorf("member TTL %s accepted", ttl)
+		}
+	}
+	for _, ttl := range []time.Duration{0, time.Millisecond} {
+		if err := NewNode(WithMemberTTL(ttl)).validate(); err != nil {
+			t.Errorf("member TTL %s rejected: %v", ttl, err)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package multicast
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	sdkerrors "github.com/swlee3306/common-sdk/errors"
+	"github.com/swlee3306/common-sdk/logging"
+)
+
+// Middleware wraps a handler, e.g. to log, time or guard it.
+type Middleware func(next HandlerFunc) HandlerFunc
+
+func Use(mw ...Middleware) {
+	defaultNode.Use(mw...)
+}
+
+// Use wraps every handler of the node in mw. The first middleware given is
+// the outermost; middleware registered with UseFor runs inside it.
+func (n *Node) Use(mw ...Middleware) {
+	n.handlersMu.Lock()
+	defer n.handlersMu.Unlock()
+	n.middleware = append(n.middleware, mw...)
+}
+
+// UseFor wraps only the handler of msgType in mw.
+func (n *Node) UseFor(msgType string, mw ...Middleware) {
+	n.handlersMu.Lock()
+	defer n.handlersMu.Unlock()
+	n.typeMiddleware[msgType] = append(n.typeMiddleware[msgType], mw...)
+}
+
+func chain(h HandlerFunc, mw []Middleware) HandlerFunc {
+	for i := len(mw) - 1; i >= 0; i-- {
+		h = mw[i](h)
+	}
+	return h
+}
+
+// LoggingMiddleware logs every handled message and its outcome with logger.
+func LoggingMiddleware(logger *logging.Logger) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, payload json.RawMessage, meta Meta) error {
+			start := time.Now()
+			err := next(ctx, payload, meta)
+
+			fields := map[string]interface{}{
+				"type":      meta.Type,
+				"messageId": meta.MessageID,
+				"interface": meta.Interface,
+				"group":     meta.Group,
+				"duration":  time.Since(start).String(),
+			}
+			if meta.Source != nil {
+				fields["source"] = meta.Source.String()
+			}
+			if err != nil {
+				fields["error"] = err.Error()
+				logger.Error("multicast handler failed", fields)
+			} else {
+				logger.Info("multicast message handled", fields)
+			}
+			return err
+		}
+	}
+}
+
+// ProcessingRecorder is the part of metrics.Metrics MetricsMiddleware uses.
+type ProcessingRecorder interface {
+	RecordMessageReceived(size int)
+	RecordProcessingTime(duration time.Duration)
+	RecordError()
+}
+
+// MetricsMiddleware records received messages, handler processing time and
+// handler errors, typically into a *metrics.Metrics.
+func MetricsMiddleware(m ProcessingRecorder) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, payload json.RawMessage, meta Meta) error {
+			m.RecordMessageReceived(len(payload))
+			start := time.Now()
+			err := next(ctx, payload, meta)
+			m.RecordProcessingTime(time.Since(start))
+			if err != nil {
+				m.RecordError()
+			}
+			return err
+		}
+	}
+}
+
+// RecoverMiddleware turns a handler panic into an internal errors.SDKError.
+func RecoverMiddleware() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, payload json.RawMessage, meta Meta) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					err = sdkerrors.NewInternalError("multicast handler panicked", fmt.Errorf("%v", r)).
+						WithDetail("type", meta.Type).
+						WithDetail("messageId", meta.MessageID)
+				}
+			}()
+			return next(ctx, payload, meta)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package multicast
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	sdkerrors "github.com/swlee3306/common-sdk/errors"
+)
+
+func TestMiddlewareOrder(t *testing.T) {
+	n := NewNode()
+	var calls []string
+	trace := func(name string) Middleware {
+		return func(next HandlerFunc) HandlerFunc {
+			return func(ctx context.Context, payload json.RawMessage, meta Meta) error {
+				calls = append(calls, name)
+				return next(ctx, payload, meta)
+			}
+		}
+	}
+
+	n.RegisterHandler("a", func(payload json.RawMessage, addr string) error {
+		calls = append(calls, "handler")
+		return nil
+	})
+	n.Use(trace("outer"), trace("inner"))
+	n.UseFor("a", trace("typed"))
+	n.UseFor("b", trace("other"))
+
+	h, _ := n.handler("a")
+	h(context.Background(), nil, Meta{Type: "a"})
+	if got := strings.Join(calls, ","); got != "outer,inner,typed,handler" {
+		t.Errorf("unexpected call order %s", got)
+	}
+}
+
+func TestRecoverMiddleware(t *testing.T) {
+	h := RecoverMiddleware()(func(ctx context.Context, payload json.RawMessage, meta Meta) error {
+		panic("boom")
+	})
+
+	err := h(context.Background(), nil, Meta{Type: "a", MessageID: "m1"})
+	var sdkErr *sdkerrors.SDKError
+	if !errors.As(err, &sdkErr) || sdkErr.Type != sdkerrors.InternalError {
+		t.Fatalf("expected internal SDKError, got %v", err)
+	}
+	if sdkErr.Details["type"] != "a" {
+		t.Errorf("missing message type in details: %v", sdkErr.Details)
+	}
+}
+
+type fakeRecorder struct {
+	received, errors int
+	durations        []time.Duration
+}
+
+func (f *fakeRecorder) RecordMessageReceived(size int)       { f.received++ }
+func (f *fakeRecorder) RecordProcessingTime(d time.Duration) { f.durations = append(f.durations, d) }
+func (f *fakeRecorder) RecordError()                         { f.errors++ }
+
+func TestMetricsMiddleware(t *testing.T) {
+	rec := &fakeRecorder{}
+	h := MetricsMiddleware(rec)(func(ctx context.Context, payload json.RawMessage, meta Meta) error {
+		return errors.New("failed")
+	})
+	h(context.Background(), json.RawMessage(`{}`), Meta{})
+
+	if rec.received != 1 || rec.errors != 1 || len(rec.durations) != 1 {
+		t.Errorf("unexpected recordings %+v", rec)
+	}
+}
@@ -0,0 +1,202 @@
+package multicast
+
+import (
+	"context"
+	"log"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/swlee3306/common-sdk/encryption"
+)
+
+// Node is an independent multicast participant. Each node owns its own
+// handler registry and host table, so several groups can run side by side
+// in one process.
+type Node struct {
+	handlers       map[string]HandlerFunc
+	handlersMu     sync.RWMutex
+	middleware     []Middleware
+	typeMiddleware map[string][]Middleware
+	dispatchConfig DispatchConfig
+
+	reassemblyLimits ReassemblyLimits
+	dedup            *dedupCache
+
+	hostData     map[string]Member
+	hostDataLock sync.RWMutex
+	memberTTL    time.Duration
+	subscribers  subscribers
+	snapshot     *snapshotConfig
+	restoredAt   time.Time
+
+	localInfo    HostInfoReceiver
+	localChanged chan struct{}
+	announcers   atomic.Int32
+
+	hostname  string
+	group     string
+	groupLock sync.RWMutex
+	mtu       int
+	tuning    Tuning
+
+	wireFormat WireFormat
+
+	ifaceFilter *InterfaceFilter
+	ifaceWatch  time.Duration
+
+	reliable *ReliableConfig
+	window   *retransmitWindow
+
+	encryptor      *encryption.Encryptor
+	allowPlaintext bool
+	compression    *compressionConfig
+	authKey        []byte
+	replay         *replayCache
+
+	requests *requestTable
+	topics   topicTable
+
+	// send transmits an envelope; tests replace it to capture outgoing messages.
+	send func(ctx context.Context, addr string, env MessageEnvelope) error
+	// localAddrs returns the addresses the node announces; tests replace it.
+	localAddrs func() []string
+
+	stats *counters
+}
+
+type Option func(*Node)
+
+// WithHostname overrides the name the node announces itself with (defaults to os.Hostname).
+func WithHostname(hostname string) Option {
+	return func(n *Node) {
+		n.hostname = hostname
+	}
+}
+
+// WithGroup sets the multicast group address (host:port) the node replies on.
+func WithGroup(addr string) Option {
+	return func(n *Node) {
+		n.group = addr
+	}
+}
+
+// WithMTU sets the MTU used when the node sends replies. The read buffer of
+// its receivers grows with it so they can read what peers with the same MTU
+// send.
+func WithMTU(mtu int) Option {
+	return func(n *Node) {
+		n.mtu = mtu
+		if n.tuning.ReadBufferSize < mtu {
+			n.tuning.ReadBufferSize = mtu
+		}
+	}
+}
+
+func NewNode(opts ...Option) *Node {
+	n := &Node{
+		handlers:         make(map[string]HandlerFunc),
+		typeMiddleware:   make(map[string][]Middleware),
+		dispatchConfig:   DefaultDispatchConfig(),
+		reassemblyLimits: DefaultReassemblyLimits(),
+		dedup:            newDedupCache(),
+		hostData:         make(map[string]Member),
+		mtu:              1500,
+		tuning:           DefaultTuning(),
+		localChanged:     make(chan struct{}, 1),
+		requests:         newRequestTable(),
+		stats:            newCounters(),
+	}
+	n.send = n.multicastEnvelope
+	n.localAddrs = func() []string { return localIPs(true, n.ifaceFilter) }
+	n.subscribers.subs = make(map[*subscription]struct{})
+	n.topics.subs = make(map[*topicSubscription]struct{})
+	for _, opt := range opts {
+		opt(n)
+	}
+	// 호스트명은 한 번만 조회해 Init, Announce, 로컬 정보 갱신이 함께 사용
+	if n.hostname == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			log.Printf("Failed to get hostname: %v", err)
+		}
+		n.hostname = hostname
+	}
+	return n
+}
+
+var defaultNode = NewNode()
+
+// DefaultNode returns the node used by the package-level functions.
+func DefaultNode() *Node {
+	return defaultNode
+}
+
+// Group returns the multicast group address the node is bound to.
+func (n *Node) Group() string {
+	n.groupLock.RLock()
+	defer n.groupLock.RUnlock()
+	return n.group
+}
+
+// senderOptions are the message transformations every sender of the node uses.
+func (n *Node) senderOptions() []SenderOption {
+	var opts []SenderOption
+	if n.encryptor != nil {
+		opts = append(opts, WithEncryptor(n.encryptor))
+	}
+	if c := n.compression; c != nil {
+		opts = append(opts, WithCompressor(c.algorithm, c.threshold))
+	}
+	if n.authKey != nil {
+		opts = append(opts, WithHMACKey(n.authKey))
+	}
+	return opts
+}
+
+// NewSender creates a sender that uses the node's MTU and message options.
+// In reliable mode it records its messages in the node's retransmission
+// window instead of blindly repeating every fragment.
+func (n *Node) NewSender(addr string, opts ...SenderOption) (*Sender, error) {
+	if err := n.validate(); err != nil {
+		return nil, err
+	}
+
+	base := append(n.senderTuning(), n.senderOptions()...)
+	if n.window != nil {
+		base = append(base,
+			WithSendPolicy(SendPolicy{Repeats: 1, FragmentGap: n.tuning.FragmentGap}),
+			withRetransmitWindow(n.window))
+	}
+	return NewSender(addr, n.mtu, append(base, opts...)...)
+}
+
+// SendWithEnvelope sends typ and payload to addr through the node.
+func (n *Node) SendWithEnvelope(addr string, typ string, payload interface{}) error {
+	return n.sendEnvelope(context.Background(), addr, MessageEnvelope{
+		Type:    typ,
+		Payload: payload,
+	})
+}
+
+func (n *Node) sendEnvelope(ctx context.Context, addr string, env MessageEnvelope) error {
+	return n.send(ctx, addr, env)
+}
+
+func (n *Node) multicastEnvelope(ctx context.Context, addr string, env MessageEnvelope) error {
+	var opts []SenderOption
+	if n.window == nil {
+		opts = append(opts, WithSendPolicy(SendPolicy{
+			Repeats:     n.tuning.SendRepeats,
+			FragmentGap: n.tuning.FragmentGap,
+			RepeatGap:   n.tuning.RepeatGap,
+		}))
+	}
+
+	s, err := n.NewSender(addr, opts...)
+	if err != nil {
+		return err
+	}
+	return s.Start(ctx, env)
+}
@@ -0,0 +1,68 @@
+package multicast
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestNodesAreIndependent(t *testing.T) {
+	a := NewNode(WithHostname("node-a"))
+	b := NewNode(WithHostname("node-b"))
+
+	a.RegisterHandler("custom", func(payload json.RawMessage, addr string) error { return nil })
+	if _, ok := b.handler("custom"); ok {
+		t.Fatalf("handler registered on node a leaked into node b")
+	}
+
+	info := HostInfoReceiver{Hostname: "peer", IPs: []string{"10.0.0.1/24"}}
+	if err := a.handleHostInfo(context.Background(), info, Meta{}); err != nil {
+		t.Fatalf("handleHostInfo failed: %v", err)
+	}
+
+	if _, ok := a.GetHostData()["peer"]; !ok {
+		t.Errorf("expected peer in node a host data")
+	}
+	if _, ok := b.GetHostData()["peer"]; ok {
+		t.Errorf("host data of node a leaked into node b")
+	}
+}
+
+func TestGetHostDataReturnsCopy(t *testing.T) {
+	n := NewNode(WithHostname("local"))
+	if err := n.handleHostInfo(context.Background(), HostInfoReceiver{Hostname: "peer"}, Meta{}); err != nil {
+		t.Fatalf("handleHostInfo failed: %v", err)
+	}
+
+	copied := n.GetHostData()
+	delete(copied, "peer")
+
+	if _, ok := n.GetHostData()["peer"]; !ok {
+		t.Errorf("modifying the returned map changed the node's host data")
+	}
+}
+
+func TestGroupSetByStartReceivers(t *testing.T) {
+	n := NewNode(WithHostname("h1"))
+	n.Init()
+
+	// StartReceivers 와 동시에 읽어도 안전해야 함 (-race)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			n.Group()
+		}
+	}()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r, err := n.StartReceivers(ctx, "239.0.0.83:9983")
+	<-done
+	if err != nil {
+		t.Skipf("cannot start receivers: %v", err)
+	}
+	r.Stop()
+	if got := n.Group(); got != "239.0.0.83:9983" {
+		t.Errorf("Group() = %q after StartReceivers", got)
+	}
+}
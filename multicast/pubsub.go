@@ -0,0 +1,233 @@
+package multicast
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// topicMessageType is the reserved message type published topic messages
+// are carried in.
+const topicMessageType = "_topic"
+
+// Topics are dot-separated, e.g. "metrics.cpu.host1". In subscription
+// patterns "*" matches exactly one segment and "#" matches any number of
+// segments, including none.
+const (
+	topicSeparator      = "."
+	topicSingleWildcard = "*"
+	topicMultiWildcard  = "#"
+)
+
+// TopicMessage is a message delivered to a topic subscription.
+type TopicMessage struct {
+	Topic   string
+	Payload json.RawMessage
+	Meta    Meta
+}
+
+type topicEnvelope struct {
+	Topic string `json:"topic"`
+	Data  any    `json:"data"`
+}
+
+type topicRoute struct {
+	pattern []string
+	group   string
+	err     error
+}
+
+type topicSubscription struct {
+	pattern []string
+	ch      chan TopicMessage
+}
+
+// topicTable holds the topic routes and subscriptions of a node.
+type topicTable struct {
+	mu     sync.RWMutex
+	routes []topicRoute
+	subs   map[*topicSubscription]struct{}
+}
+
+// WithTopicGroup publishes topics matching pattern to group (host:port)
+// instead of the node's group. Routes are tried in the order given, and
+// StartReceivers also listens on every routed group. An invalid pattern is
+// reported by StartReceivers and NewSender.
+func WithTopicGroup(pattern, group string) Option {
+	return func(n *Node) {
+		segments, err := parseTopic(pattern, true)
+		if err != nil {
+			err = fmt.Errorf("invalid topic group pattern: %w", err)
+		}
+		n.topics.routes = append(n.topics.routes, topicRoute{
+			pattern: segments,
+			group:   group,
+			err:     err,
+		})
+	}
+}
+
+func parseTopic(topic string, wildcards bool) ([]string, error) {
+	if topic == "" {
+		return nil, fmt.Errorf("empty topic")
+	}
+	segments := strings.Split(topic, topicSeparator)
+	for _, seg := range segments {
+		if seg == "" {
+			return nil, fmt.Errorf("invalid topic %q: empty segment", topic)
+		}
+		if seg == topicSingleWildcard || seg == topicMultiWildcard {
+			if !wildcards {
+				return nil, fmt.Errorf("invalid topic %q: wildcards are only allowed in subscriptions", topic)
+			}
+			continue
+		}
+		if strings.ContainsAny(seg, topicSingleWildcard+topicMultiWildcard) {
+			return nil, fmt.Errorf("invalid topic %q: wildcard must be a whole segment", topic)
+		}
+	}
+	return segments, nil
+}
+
+// matchTopic reports whether topic matches pattern.
+func matchTopic(pattern, topic []string) bool {
+	if len(pattern) == 0 {
+		return len(topic) == 0
+	}
+	switch pattern[0] {
+	case topicMultiWildcard:
+		for i := 0; i <= len(topic); i++ {
+			if matchTopic(pattern[1:], topic[i:]) {
+				return true
+			}
+		}
+		return false
+	case topicSingleWildcard:
+		return len(topic) > 0 && matchTopic(pattern[1:], topic[1:])
+	default:
+		return len(topic) > 0 && pattern[0] == topic[0] && matchTopic(pattern[1:], topic[1:])
+	}
+}
+
+// topicGroup returns the group topic is published to.
+func (n *Node) topicGroup(topic []string) string {
+	n.topics.mu.RLock()
+	defer n.topics.mu.RUnlock()
+	for _, route := range n.topics.routes {
+		if matchTopic(route.pattern, topic) {
+			return route.group
+		}
+	}
+	return n.Group()
+}
+
+// topicRoutesErr returns the first error in the configured topic routes.
+func (n *Node) topicRoutesErr() error {
+	n.topics.mu.RLock()
+	defer n.topics.mu.RUnlock()
+	for _, route := range n.topics.routes {
+		if route.err != nil {
+			return route.err
+		}
+	}
+	return nil
+}
+
+// topicGroups returns every group topics are routed to.
+func (n *Node) topicGroups() []string {
+	n.topics.mu.RLock()
+	defer n.topics.mu.RUnlock()
+	groups := make([]string, 0, len(n.topics.routes))
+	for _, route := range n.topics.routes {
+		groups = append(groups, route.group)
+	}
+	return groups
+}
+
+func (n *Node) topicSubscriptions() int {
+	n.topics.mu.RLock()
+	defer n.topics.mu.RUnlock()
+	return len(n.topics.subs)
+}
+
+func Publish(ctx context.Context, topic string, payload any) error {
+	return defaultNode.Publish(ctx, topic, payload)
+}
+
+// Publish sends payload on topic to the group the topic is routed to.
+func (n *Node) Publish(ctx context.Context, topic string, payload any) error {
+	segments, err := parseTopic(topic, false)
+	if err != nil {
+		return err
+	}
+	group := n.topicGroup(segments)
+	if group == "" {
+		return fmt.Errorf("no multicast group configured for topic %s", topic)
+	}
+	return n.sendEnvelope(ctx, group, MessageEnvelope{
+		Type:    topicMessageType,
+		Payload: topicEnvelope{Topic: topic, Data: payload},
+	})
+}
+
+func SubscribeTopic(pattern string, buffer int) (<-chan TopicMessage, func(), error) {
+	return defaultNode.SubscribeTopic(pattern, buffer)
+}
+
+// SubscribeTopic delivers messages whose topic matches pattern to the
+// returned channel until the returned function is called. Messages that do
+// not fit into the buffer are dropped and counted in Stats, so a slow
+// consumer never blocks the receivers.
+func (n *Node) SubscribeTopic(pattern string, buffer int) (<-chan TopicMessage, func(), error) {
+	segments, err := parseTopic(pattern, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	sub := &topicSubscription{pattern: segments, ch: make(chan TopicMessage, buffer)}
+
+	n.topics.mu.Lock()
+	n.topics.subs[sub] = struct{}{}
+	n.topics.mu.Unlock()
+
+	var once sync.Once
+	return sub.ch, func() {
+		once.Do(func() {
+			n.topics.mu.Lock()
+			delete(n.topics.subs, sub)
+			n.topics.mu.Unlock()
+			close(sub.ch)
+		})
+	}, nil
+}
+
+func (n *Node) handleTopic(payload json.RawMessage, meta Meta) error {
+	var env struct {
+		Topic string          `json:"topic"`
+		Data  json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(payload, &env); err != nil {
+		return fmt.Errorf("failed to decode topic message: %w", err)
+	}
+	segments, err := parseTopic(env.Topic, false)
+	if err != nil {
+		return err
+	}
+
+	msg := TopicMessage{Topic: env.Topic, Payload: env.Data, Meta: meta}
+
+	n.topics.mu.RLock()
+	defer n.topics.mu.RUnlock()
+	for sub := range n.topics.subs {
+		if !matchTopic(sub.pattern, segments) {
+			continue
+		}
+		select {
+		case sub.ch <- msg:
+		default:
+			n.stats.inc("dropped_topic_overflow")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package multicast
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMatchTopic(t *testing.T) {
+	tests := []struct {
+		pattern, topic string
+		want           bool
+	}{
+		{"metrics.cpu", "metrics.cpu", true},
+		{"metrics.cpu", "metrics.mem", false},
+		{"metrics.*", "metrics.cpu", true},
+		{"metrics.*", "metrics.cpu.host1", false},
+		{"metrics.*", "metrics", false},
+		{"deploy.#", "deploy", true},
+		{"deploy.#", "deploy.web.v2", true},
+		{"deploy.#", "deployment", false},
+		{"#", "anything.at.all", true},
+		{"*.cpu.#", "metrics.cpu", true},
+		{"*.cpu.#", "metrics.mem.cpu", false},
+		{"a.#.z", "a.b.c.z", true},
+		{"a.#.z", "a.z", true},
+	}
+	for _, tt := range tests {
+		got := matchTopic(strings.Split(tt.pattern, "."), strings.Split(tt.topic, "."))
+		if got != tt.want {
+			t.Errorf("matchTopic(%q, %q) = %v, want %v", tt.pattern, tt.topic, got, tt.want)
+		}
+	}
+}
+
+func TestParseTopicRejectsInvalid(t *testing.T) {
+	for _, topic := range []string{"", "a..b", "metrics.*", "deploy.#", "met*rics"} {
+		if _, err := parseTopic(topic, false); err == nil {
+			t.Errorf("expected %q to be rejected as a topic", topic)
+		}
+	}
+	if _, err := parseTopic("metrics.*.#", true); err != nil {
+		t.Errorf("valid pattern rejected: %v", err)
+	}
+}
+
+func TestTopicDeliveryIsBuffered(t *testing.T) {
+	n := NewNode()
+	metrics, cancel, err := n.SubscribeTopic("metrics.*", 1)
+	if err != nil {
+		t.Fatalf("subscribe failed: %v", err)
+	}
+	defer cancel()
+	deploys, cancelDeploys, _ := n.SubscribeTopic("deploy.#", 4)
+	defer cancelDeploys()
+
+	for _, topic := range []string{"metrics.cpu", "metrics.mem", "deploy.web"} {
+		payload, _ := json.Marshal(topicEnvelope{Topic: topic, Data: 1})
+		if err := n.handleTopic(payload, Meta{}); err != nil {
+			t.Fatalf("handleTopic failed: %v", err)
+		}
+	}
+
+	if msg := <-metrics; msg.Topic != "metrics.cpu" {
+		t.Errorf("unexpected message on metrics subscription: %s", msg.Topic)
+	}
+	if msg := <-deploys; msg.Topic != "deploy.web" || string(msg.Payload) != "1" {
+		t.Errorf("unexpected message on deploy subscription: %+v", msg)
+	}
+	if n.Stats()["dropped_topic_overflow"] != 1 {
+		t.Errorf("expected overflow to be counted, got %v", n.Stats())
+	}
+}
+
+func TestPublishUsesTopicGroup(t *testing.T) {
+	n := NewNode(WithGroup("239.0.0.1:9999"), WithTopicGroup("metrics.#", "239.0.0.2:9999"))
+	var sentTo []string
+	n.send = func(ctx context.Context, addr string, env MessageEnvelope) error {
+		sentTo = append(sentTo, addr)
+		return nil
+	}
+
+	for _, topic := range []string{"metrics.cpu", "deploy.web"} {
+		if err := n.Publish(context.Background(), topic, 1); err != nil {
+			t.Fatalf("publish %s: %v", topic, err)
+		}
+	}
+	if want := []string{"239.0.0.2:9999", "239.0.0.1:9999"}; !reflect.DeepEqual(sentTo, want) {
+		t.Errorf("published to %v, want %v", sentTo, want)
+	}
+}
+
+func TestStartReceiversJoinsTopicGroups(t *testing.T) {
+	addr, _ := net.ResolveUDPAddr("udp", "239.0.0.84:9984")
+	ifaces, err := groupInterfaces(addr, nil)
+	if err != nil || len(ifaces) == 0 {
+		t.Skip("no multicast interface available")
+	}
+	n := NewNode(WithInterfaceFilter(InterfaceFilter{Include: []string{ifaces[0].Name}}),
+		WithTopicGroup("metrics.#", "239.0.0.85:9985"))
+	_, cancelSub, _ := n.SubscribeTopic("metrics.#", 1)
+	defer cancelSub()
+
+	r, err := n.StartReceivers(context.Background(), addr.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Stop()
+	want := []string{ifaces[0].Name, ifaces[0].Name + " 239.0.0.85:9985"}
+	if got := r.Interfaces(); !reflect.DeepEqual(got, want) {
+		t.Errorf("receivers on %v, want %v", got, want)
+	}
+}
+
+func TestTopicGroupPatternValidated(t *testing.T) {
+	n := NewNode(WithTopicGroup("metrics..cpu", "239.0.0.2:9999"))
+	_, cancelSub, _ := n.SubscribeTopic("metrics.#", 1)
+	defer cancelSub()
+
+	if _, err := n.StartReceivers(context.Background(), "239.0.0.1:9999"); err == nil {
+		t.Error("StartReceivers accepted an invalid topic group pattern")
+	}
+	if _, err := n.NewSender("239.0.0.1:9999"); err == nil {
+		t.Error("NewSender accepted an invalid topic group pattern")
+	}
+}
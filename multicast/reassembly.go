@@ -0,0 +1,315 @@
+package multicast
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// ReassemblyLimits bound the memory a receiver spends on incomplete
+// messages: MaxMessages in flight, MaxFragments and MaxMessageBytes per
+// message, and MaxSenderBytes buffered for a single source address.
+// MaxMessages also caps how many completed message IDs are remembered.
+type ReassemblyLimits struct {
+	MaxMessages     int
+	MaxFragments    int
+	MaxMessageBytes int
+	MaxSenderBytes  int
+}
+
+func DefaultReassemblyLimits() ReassemblyLimits {
+	return ReassemblyLimits{
+		MaxMessages:     1024,
+		MaxFragments:    1024,
+		MaxMessageBytes: 4 << 20,
+		MaxSenderBytes:  16 << 20,
+	}
+}
+
+// WithReassemblyLimits sets the reassembly limits of every receiver of the
+// node. Zero fields fall back to DefaultReassemblyLimits. Fragments beyond
+// the limits are rejected and evicted messages are counted in Stats.
+func WithReassemblyLimits(limits ReassemblyLimits) Option {
+	defaults := DefaultReassemblyLimits()
+	if limits.MaxMessages <= 0 {
+		limits.MaxMessages = defaults.MaxMessages
+	}
+	if limits.MaxFragments <= 0 {
+		limits.MaxFragments = defaults.MaxFragments
+	}
+	if limits.MaxMessageBytes <= 0 {
+		limits.MaxMessageBytes = defaults.MaxMessageBytes
+	}
+	if limits.MaxSenderBytes <= 0 {
+		limits.MaxSenderBytes = defaults.MaxSenderBytes
+	}
+	return func(n *Node) {
+		n.reassemblyLimits = limits
+	}
+}
+
+// messageBuffer collects the fragments of one message until it is complete.
+type messageBuffer struct {
+	fragments map[int][]byte
+	received  int
+	total     int
+	parity    int
+	flags     uint8
+	sender    string
+	bytes     int
+	createdAt time.Time
+	updatedAt time.Time
+
+	// nacks counts the NACKs sent for this message in reliable mode.
+	nacks    int
+	nackedAt time.Time
+}
+
+// needed is the number of fragments required to rebuild the message.
+func (b *messageBuffer) needed() int {
+	return b.total - b.parity
+}
+
+// assemble joins the data fragments, rebuilding lost ones from parity.
+func (b *messageBuffer) assemble() ([]byte, error) {
+	if b.parity == 0 {
+		var full []byte
+		for i := 1; i <= b.total; i++ {
+			part, ok := b.fragments[i]
+			if !ok {
+				return nil, fmt.Errorf("missing fragment %d", i)
+			}
+			full = append(full, part...)
+		}
+		return full, nil
+	}
+
+	if b.total > maxFECShards {
+		return nil, fmt.Errorf("fec message has %d fragments, limit is %d", b.total, maxFECShards)
+	}
+
+	shards := make([][]byte, b.total)
+	shardSize := -1
+	for seq, part := range b.fragments {
+		if seq < 1 || seq > b.total {
+			continue
+		}
+		if shardSize >= 0 && len(part) != shardSize {
+			return nil, fmt.Errorf("fec fragments differ in size")
+		}
+		shardSize = len(part)
+		shards[seq-1] = part
+	}
+	data, err := reconstructData(shards, b.needed())
+	if err != nil {
+		return nil, err
+	}
+	return unframeFEC(data)
+}
+
+// missing returns the sequence numbers that have not arrived yet.
+func (b *messageBuffer) missing() []int {
+	var seqs []int
+	for i := 1; i <= b.total; i++ {
+		if _, ok := b.fragments[i]; !ok {
+			seqs = append(seqs, i)
+		}
+	}
+	return seqs
+}
+
+// reassembled is a complete message body as it came off the wire.
+type reassembled struct {
+	id    string
+	flags uint8
+	body  []byte
+}
+
+// reassembler is the per-receiver fragment cache. The IDs of completed
+// messages are remembered in done, so repeats, retransmissions and the late
+// fragments of FEC messages do not start a new buffer (and NACKs for it).
+type reassembler struct {
+	mu          sync.Mutex
+	cache       map[string]*messageBuffer
+	done        map[string]time.Time
+	doneOrder   []string // completion order of done, oldest first
+	senderBytes map[string]int
+	limits      ReassemblyLimits
+	stats       *counters
+}
+
+func newReassembler(limits ReassemblyLimits, stats *counters) *reassembler {
+	return &reassembler{
+		cache:       make(map[string]*messageBuffer),
+		done:        make(map[string]time.Time),
+		senderBytes: make(map[string]int),
+		limits:      limits,
+		stats:       stats,
+	}
+}
+
+// remove drops a buffered message and releases its bytes from its sender's budget.
+func (r *reassembler) remove(id string, entry *messageBuffer) {
+	delete(r.cache, id)
+	if r.senderBytes[entry.sender] -= entry.bytes; r.senderBytes[entry.sender] <= 0 {
+		delete(r.senderBytes, entry.sender)
+	}
+}
+
+// evictOldest makes room for a new message by dropping the least recently
+// updated one.
+func (r *reassembler) evictOldest() {
+	var oldestID string
+	var oldest *messageBuffer
+	for id, entry := range r.cache {
+		if oldest == nil || entry.updatedAt.Before(oldest.updatedAt) {
+			oldestID, oldest = id, entry
+		}
+	}
+	if oldest != nil {
+		r.remove(oldestID, oldest)
+		r.stats.inc("evicted_message_limit")
+	}
+}
+
+// check rejects fragments whose header is out of range or disagrees with
+// the fragments already buffered for the message.
+func (r *reassembler) check(frag Fragment, entry *messageBuffer) string {
+	switch {
+	case frag.Total < 1 || frag.Total > r.limits.MaxFragments:
+		return "rejected_fragment_total"
+	case frag.Seq < 1 || frag.Seq > frag.Total:
+		return "rejected_fragment_seq"
+	case frag.Parity < 0 || frag.Parity >= frag.Total:
+		return "rejected_fragment_parity"
+	case entry != nil && (entry.total != frag.Total || entry.parity != frag.Parity || entry.flags != frag.Flags):
+		return "rejected_fragment_mismatch"
+	}
+	return ""
+}
+
+// add stores frag from sender and returns the full message once enough
+// fragments arrived.
+func (r *reassembler) add(frag Fragment, sender string) (reassembled, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.done[frag.MessageID]; ok {
+		return reassembled{}, false
+	}
+
+	entry, exists := r.cache[frag.MessageID]
+	if reason := r.check(frag, entry); reason != "" {
+		r.stats.inc(reason)
+		return reassembled{}, false
+	}
+
+	if exists {
+		if _, ok := entry.fragments[frag.Seq]; ok {
+			entry.updatedAt = time.Now()
+			return reassembled{}, false
+		}
+	}
+
+	size := len(frag.Data)
+	if r.senderBytes[sender]+size > r.limits.MaxSenderBytes {
+		r.stats.inc("rejected_sender_bytes")
+		return reassembled{}, false
+	}
+
+	now := time.Now()
+	if !exists {
+		if len(r.cache) >= r.limits.MaxMessages {
+			r.evictOldest()
+		}
+		entry = &messageBuffer{
+			fragments: make(map[int][]byte),
+			total:     frag.Total,
+			parity:    frag.Parity,
+			flags:     frag.Flags,
+			sender:    sender,
+			createdAt: now,
+		}
+		r.cache[frag.MessageID] = entry
+	}
+	entry.updatedAt = now
+
+	if entry.bytes+size > r.limits.MaxMessageBytes {
+		r.remove(frag.MessageID, entry)
+		r.stats.inc("evicted_message_bytes")
+		log.Printf("Dropped message %s: exceeds %d bytes", frag.MessageID, r.limits.MaxMessageBytes)
+		return reassembled{}, false
+	}
+	entry.fragments[frag.Seq] = frag.Data
+	entry.received++
+	entry.bytes += size
+	r.senderBytes[entry.sender] += size
+
+	if entry.received < entry.needed() {
+		return reassembled{}, false
+	}
+	r.remove(frag.MessageID, entry)
+	r.markDone(frag.MessageID, now)
+
+	full, err := entry.assemble()
+	if err != nil {
+		log.Printf("Failed to reassemble message %s: %v", frag.MessageID, err)
+		return reassembled{}, false
+	}
+	return reassembled{id: frag.MessageID, flags: entry.flags, body: full}, true
+}
+
+// markDone remembers a completed message ID, forgetting the oldest one once
+// MaxMessages are remembered.
+func (r *reassembler) markDone(id string, now time.Time) {
+	if len(r.doneOrder) >= r.limits.MaxMessages {
+		delete(r.done, r.doneOrder[0])
+		r.doneOrder = r.doneOrder[1:]
+		r.stats.inc("evicted_done_limit")
+	}
+	r.done[id] = now
+	r.doneOrder = append(r.doneOrder, id)
+}
+
+// expire drops messages that have been incomplete for longer than maxAge.
+func (r *reassembler) expire(maxAge time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, entry := range r.cache {
+		if time.Since(entry.createdAt) > maxAge {
+			r.remove(id, entry)
+			r.stats.inc("evicted_expired")
+		}
+	}
+	for len(r.doneOrder) > 0 && time.Since(r.done[r.doneOrder[0]]) > maxAge {
+		delete(r.done, r.doneOrder[0])
+		r.doneOrder = r.doneOrder[1:]
+	}
+}
+
+// pendingNACKs returns the NACKs due for incomplete messages that have been
+// quiet for cfg.NACKDelay. Messages that exhausted cfg.MaxNACKs are dropped.
+func (r *reassembler) pendingNACKs(cfg ReliableConfig) []nack {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	var nacks []nack
+	for id, entry := range r.cache {
+		if now.Sub(entry.updatedAt) < cfg.NACKDelay || now.Sub(entry.nackedAt) < cfg.NACKDelay {
+			continue
+		}
+		if entry.nacks >= cfg.MaxNACKs {
+			log.Printf("Giving up on message %s after %d NACKs, missing %v", id, entry.nacks, entry.missing())
+			r.remove(id, entry)
+			r.stats.inc("evicted_nack_limit")
+			continue
+		}
+
+		entry.nacks++
+		entry.nackedAt = now
+		nacks = append(nacks, nack{MessageID: id, Missing: entry.missing()})
+	}
+	return nacks
+}
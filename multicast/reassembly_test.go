@@ -0,0 +1,121 @@
+package multicast
+
+import (
+	"fmt"
+	"testing"
+)
+
+func fragment(id string, seq, total int, data string) Fragment {
+	return Fragment{MessageID: id, Seq: seq, Total: total, Data: []byte(data)}
+}
+
+func TestReassemblyRejectsOutOfRangeFragments(t *testing.T) {
+	stats := newCounters()
+	r := newReassembler(ReassemblyLimits{MaxMessages: 8, MaxFragments: 4, MaxMessageBytes: 64, MaxSenderBytes: 64}, stats)
+
+	r.add(fragment("a", 0, 2, "x"), "peer")
+	r.add(fragment("a", 3, 2, "x"), "peer")
+	r.add(fragment("b", 1, 100, "x"), "peer")
+	r.add(fragment("c", 1, 2, "x"), "peer")
+	r.add(fragment("c", 2, 3, "x"), "peer")
+
+	got := stats.snapshot()
+	if got["rejected_fragment_seq"] != 2 || got["rejected_fragment_total"] != 1 || got["rejected_fragment_mismatch"] != 1 {
+		t.Errorf("unexpected rejections %v", got)
+	}
+	if len(r.cache) != 1 {
+		t.Errorf("expected only message c to be buffered, got %d", len(r.cache))
+	}
+}
+
+func TestReassemblyEvictsWhenFull(t *testing.T) {
+	stats := newCounters()
+	r := newReassembler(ReassemblyLimits{MaxMessages: 2, MaxFragments: 4, MaxMessageBytes: 64, MaxSenderBytes: 64}, stats)
+
+	for i := 0; i < 5; i++ {
+		r.add(fragment(fmt.Sprintf("m%d", i), 1, 2, "x"), "peer")
+	}
+	if len(r.cache) != 2 {
+		t.Fatalf("expected 2 buffered messages, got %d", len(r.cache))
+	}
+	if _, ok := r.cache["m4"]; !ok {
+		t.Errorf("newest message was evicted")
+	}
+	if stats.snapshot()["evicted_message_limit"] != 3 {
+		t.Errorf("unexpected evictions %v", stats.snapshot())
+	}
+}
+
+func TestReassemblyByteLimits(t *testing.T) {
+	stats := newCounters()
+	r := newReassembler(ReassemblyLimits{MaxMessages: 8, MaxFragments: 8, MaxMessageBytes: 8, MaxSenderBytes: 12}, stats)
+
+	r.add(fragment("big", 1, 3, "12345"), "peer")
+	r.add(fragment("big", 2, 3, "12345"), "peer")
+	if _, ok := r.cache["big"]; ok || stats.snapshot()["evicted_message_bytes"] != 1 {
+		t.Errorf("oversized message not evicted: %v", stats.snapshot())
+	}
+	if r.senderBytes["peer"] != 0 {
+		t.Errorf("evicted bytes not released: %d", r.senderBytes["peer"])
+	}
+
+	r.add(fragment("a", 1, 2, "123456"), "peer")
+	r.add(fragment("b", 1, 2, "123456"), "peer")
+	r.add(fragment("c", 1, 2, "1"), "peer")
+	if stats.snapshot()["rejected_sender_bytes"] != 1 {
+		t.Errorf("sender budget not enforced: %v", stats.snapshot())
+	}
+	r.add(fragment("c", 1, 2, "1"), "other")
+
+	if msg, complete := r.add(fragment("a", 2, 2, ""), "peer"); !complete || string(msg.body) != "123456" {
+		t.Fatalf("message a did not complete")
+	}
+	if r.senderBytes["peer"] != 6 {
+		t.Errorf("completed message bytes not released: %d", r.senderBytes["peer"])
+	}
+}
+
+func TestReassemblyIgnoresFragmentsAfterCompletion(t *testing.T) {
+	r := newReassembler(DefaultReassemblyLimits(), newCounters())
+
+	for seq := 1; seq <= 5; seq++ {
+		_, complete := r.add(fragment("m", seq, 5, "x"), "peer")
+		if complete != (seq == 5) {
+			t.Fatalf("fragment %d: complete = %v", seq, complete)
+		}
+	}
+
+	// 완료 후 재전송된 조각은 새 버퍼를 만들지 않아야 함
+	if _, complete := r.add(fragment("m", 3, 5, "x"), "peer"); complete {
+		t.Fatal("re-sent fragment completed the message again")
+	}
+	if len(r.cache) != 0 {
+		t.Fatalf("re-sent fragment started a new buffer")
+	}
+	if nacks := r.pendingNACKs(ReliableConfig{MaxNACKs: 3}); len(nacks) != 0 {
+		t.Fatalf("unexpected NACKs %v", nacks)
+	}
+}
+
+func TestReassemblyBoundsCompletedIDs(t *testing.T) {
+	stats := newCounters()
+	r := newReassembler(ReassemblyLimits{MaxMessages: 4, MaxFragments: 4, MaxMessageBytes: 64, MaxSenderBytes: 64}, stats)
+
+	for i := 0; i < 10; i++ {
+		r.add(fragment(fmt.Sprintf("m%d", i), 1, 1, "x"), "peer")
+	}
+	if len(r.done) != 4 || len(r.doneOrder) != 4 {
+		t.Fatalf("remembered %d completed ids, want 4", len(r.done))
+	}
+	if _, ok := r.done["m9"]; !ok {
+		t.Errorf("newest completed id forgotten")
+	}
+	if got := stats.snapshot()["evicted_done_limit"]; got != 6 {
+		t.Errorf("evicted_done_limit = %d, want 6", got)
+	}
+
+	r.expire(0)
+	if len(r.done) != 0 || len(r.doneOrder) != 0 {
+		t.Errorf("expired ids kept: %d", len(r.done))
+	}
+}
@@ -1,133 +1,225 @@
 package multicast
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net"
-	"os"
 	"sync"
 	"time"
 )
 
 type MessageHandler func(payload json.RawMessage, addr string) error
 
-var handlerRegistry = make(map[string]MessageHandler)
-
 func RegisterHandler(msgType string, handler MessageHandler) {
-	handlerRegistry[msgType] = handler
+	defaultNode.RegisterHandler(msgType, handler)
 }
 
-var hostData = make(map[string]HostInfoReceiver)
-var hostDataLock sync.RWMutex
-
 func Init() {
-	RegisterHandler("hostinfoSend", handleHostInfoSend)
-	RegisterHandler("hostinfo", handleHostInfo)
+	defaultNode.Init()
+}
 
-	hostname, err := os.Hostname()
-	if err != nil {
-		log.Printf("Failed to get hostname: %v", err)
+func RunReceivers(addr string) error {
+	return defaultNode.RunReceivers(addr)
+}
+
+func RunReceiverWithTimeoutCleanup(addr *net.UDPAddr, iface *net.Interface, multicastaddr string) error {
+	return defaultNode.RunReceiverWithTimeoutCleanup(addr, iface, multicastaddr)
+}
+
+func GetHostData() map[string]HostInfoReceiver {
+	return defaultNode.GetHostData()
+}
+
+func (n *Node) Init() {
+	n.registerHandler("hostinfoSend", n.handleHostInfoSend)
+	Handle(n, "hostinfo", n.handleHostInfo)
+	if n.reliable != nil {
+		Handle(n, nackMessageType, n.handleNACK)
+	}
+
+	if n.hostname == "" {
 		return
 	}
 
-	ips := getLocalIPs()
-	hostDataLock.Lock()
-	hostData[hostname] = HostInfoReceiver{
-		Version:      "",
-		BuildDate:    "",
-		Revision:     "",
-		Hostname:     hostname,
-		IPs:          ips,
-		Endpoint:     "",
-		EndpointPort: 0,
+	n.observe(n.hostInfo(n.hostname), time.Now())
+
+	if n.snapshot != nil {
+		if err := n.loadSnapshot(n.snapshot.path); err != nil {
+			log.Printf("Failed to restore host snapshot: %v", err)
+		}
 	}
-	hostDataLock.Unlock()
 }
 
-func RunReceivers(addr string) error {
-	if len(handlerRegistry) == 0 {
-		return fmt.Errorf("handler registry is empty — did you forget to call multicast.Init()?")
+// RunReceivers starts receivers on every multicast interface and returns
+// immediately. Receiver failures are logged; use StartReceivers to observe them.
+func (n *Node) RunReceivers(addr string) error {
+	receivers, err := n.StartReceivers(context.Background(), addr)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		for err := range receivers.Errors() {
+			log.Printf("Receiver stopped: %v", err)
+		}
+	}()
+
+	return nil
+}
+
+// StartReceivers starts one receiver per multicast interface. The receivers
+// run until ctx is cancelled or the returned handle is stopped. With
+// WithInterfaceWatch they follow interfaces as they come and go.
+func (n *Node) StartReceivers(ctx context.Context, addr string) (*Receivers, error) {
+	if n.handlerCount() == 0 && n.topicSubscriptions() == 0 {
+		return nil, fmt.Errorf("handler registry is empty — did you forget to call multicast.Init()?")
+	}
+	if err := n.validate(); err != nil {
+		return nil, err
 	}
 
 	// mcast addr
 	udpAddr, err := net.ResolveUDPAddr("udp", addr)
 	if err != nil {
-		return fmt.Errorf("failed to resolve multicast address: %w", err)
+		return nil, fmt.Errorf("failed to resolve multicast address: %w", err)
 	}
 
-	// Interfaces
-	ifaces, err := net.Interfaces()
-	if err != nil {
-		return fmt.Errorf("failed to get interfaces: %w", err)
+	n.groupLock.Lock()
+	if n.group == "" {
+		n.group = addr
 	}
+	n.groupLock.Unlock()
 
-	// for each interface
-	for _, iface := range ifaces {
-		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagMulticast == 0 {
+	// Interfaces
+	listeners := []listener{{group: addr, addr: udpAddr, primary: true}}
+
+	// topic 별 그룹도 함께 수신
+	seen := map[string]bool{addr: true}
+	for _, group := range n.topicGroups() {
+		if seen[group] {
 			continue
 		}
+		seen[group] = true
 
-		//go RunReceiver(cancel, udpAddr, &iface)
-		log.Printf("Starting receiver on interface: %s [%s]", iface.Name, iface.HardwareAddr)
-		go RunReceiverWithTimeoutCleanup(udpAddr, &iface, addr)
+		groupAddr, err := net.ResolveUDPAddr("udp", group)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve topic group %s: %w", group, err)
+		}
+		listeners = append(listeners, listener{group: group, addr: groupAddr})
+	}
+	wanted, err := n.wantedReceivers(listeners)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	ctx, cancel := context.WithCancel(ctx)
+	receivers := newReceivers(cancel, len(wanted))
+
+	pool := newWorkerPool(n.dispatchConfig, n.stats)
+	receivers.run("dispatch", func() error {
+		pool.run(ctx)
+		return nil
+	})
+
+	// for each interface
+	n.reconcile(ctx, receivers, pool, wanted)
+	if n.ifaceWatch > 0 {
+		receivers.run("interfaces", func() error {
+			return n.watchInterfaces(ctx, receivers, pool, listeners)
+		})
+	}
+	if n.memberTTL > 0 {
+		receivers.run("membership", func() error {
+			return n.runMemberExpiry(ctx)
+		})
+	}
+	if n.snapshot != nil && n.snapshot.interval > 0 {
+		receivers.run("snapshot", func() error {
+			return n.runSnapshots(ctx)
+		})
+	}
+	receivers.closeWhenDone()
+
+	return receivers, nil
 }
 
-func RunReceiverWithTimeoutCleanup(addr *net.UDPAddr, iface *net.Interface, multicastaddr string) error {
-	conn, err := net.ListenMulticastUDP("udp", iface, addr)
+func (n *Node) RunReceiverWithTimeoutCleanup(addr *net.UDPAddr, iface *net.Interface, multicastaddr string) error {
+	if err := n.validate(); err != nil {
+		return err
+	}
+	return n.receive(context.Background(), addr, iface, multicastaddr, nil)
+}
+
+// receive reads and reassembles fragments on one interface until ctx is done.
+// Handlers run on pool, or inline when pool is nil.
+func (n *Node) receive(ctx context.Context, addr *net.UDPAddr, iface *net.Interface, multicastaddr string, pool *workerPool) error {
+	conn, err := net.ListenMulticastUDP(listenNetwork(addr), iface, addr)
 	if err != nil {
 		return fmt.Errorf("failed to listen on multicast: %w", err)
 	}
 	defer conn.Close()
 
-	if err := conn.SetReadBuffer(2048); err != nil {
-		log.Printf("Warning: failed to set read buffer: %v", err)
+	t := n.tuning
+	if t.SocketReceiveBuffer > 0 {
+		if err := conn.SetReadBuffer(t.SocketReceiveBuffer); err != nil {
+			log.Printf("Warning: failed to set read buffer: %v", err)
+		}
 	}
 
-	type Fragment struct {
-		MessageID string `json:"id"`
-		Seq       int    `json:"seq"`
-		Total     int    `json:"total"`
-		Data      []byte `json:"data"`
-	}
+	cache := newReassembler(n.reassemblyLimits, n.stats)
 
-	type MessageBuffer struct {
-		fragments map[int][]byte
-		received  int
-		total     int
-		createdAt time.Time
-	}
+	var wg sync.WaitGroup
+	defer wg.Wait()
 
-	cache := make(map[string]*MessageBuffer)
-	var mu sync.Mutex
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
+	wg.Add(1)
 	go func() {
-		ticker := time.NewTicker(5 * time.Second)
+		defer wg.Done()
+		ticker := time.NewTicker(t.CleanupInterval)
 		defer ticker.Stop()
 		for {
 			select {
+			case <-ctx.Done():
+				return
 			case <-ticker.C:
-				mu.Lock()
-				for id, entry := range cache {
-					if time.Since(entry.createdAt) > 15*time.Second {
-						delete(cache, id)
-					}
-				}
-				mu.Unlock()
+				cache.expire(t.ReassemblyTimeout)
 			}
 		}
 	}()
 
-	buf := make([]byte, 2048)
+	if n.reliable != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ticker := time.NewTicker(n.reliable.NACKDelay / 2)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					if nacks := cache.pendingNACKs(*n.reliable); len(nacks) > 0 {
+						n.sendNACKs(multicastaddr, nacks)
+					}
+				}
+			}
+		}()
+	}
+
+	buf := make([]byte, t.ReadBufferSize)
 
 	for {
 		select {
+		case <-ctx.Done():
+			return nil
 		default:
-			conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
-			n, _, err := conn.ReadFromUDP(buf)
+			conn.SetReadDeadline(time.Now().Add(t.ReadDeadline))
+			size, src, err := conn.ReadFromUDP(buf)
 			if err != nil {
 				if ne, ok := err.(net.Error); ok && ne.Timeout() {
 					continue
@@ -135,93 +227,107 @@ func RunReceiverWithTimeoutCleanup(addr *net.UDPAddr, iface *net.Interface, mult
 				return fmt.Errorf("UDP read failed: %w", err)
 			}
 
-			var frag Fragment
-			if err := json.Unmarshal(buf[:n], &frag); err != nil {
-				log.Printf("Invalid fragment JSON: %v", err)
+			frag, err := decodeFragment(buf[:size])
+			if err != nil {
+				log.Printf("Invalid fragment: %v", err)
 				continue
 			}
 
-			mu.Lock()
-			entry, exists := cache[frag.MessageID]
-			if !exists {
-				entry = &MessageBuffer{
-					fragments: make(map[int][]byte),
-					total:     frag.Total,
-					createdAt: time.Now(),
-				}
-				cache[frag.MessageID] = entry
-			}
-			if _, ok := entry.fragments[frag.Seq]; !ok {
-				entry.fragments[frag.Seq] = frag.Data
-				entry.received++
+			if msg, complete := cache.add(frag, src.IP.String()); complete {
+				n.dispatch(ctx, msg, Meta{
+					MessageID:  msg.id,
+					Source:     src,
+					Interface:  iface.Name,
+					Group:      multicastaddr,
+					ReceivedAt: time.Now(),
+				}, pool)
 			}
+		}
+	}
+}
 
-			if entry.received == entry.total {
-				success := true
-				totalLen := 0
-				for i := 1; i <= entry.total; i++ {
-					part, ok := entry.fragments[i]
-					if !ok {
-						log.Printf("Missing fragment %d in message %s", i, frag.MessageID)
-						success = false
-						break
-					}
-					totalLen += len(part)
-				}
-
-				if success {
-					full := make([]byte, totalLen)
-					offset := 0
-					for i := 1; i <= entry.total; i++ {
-						copy(full[offset:], entry.fragments[i])
-						offset += len(entry.fragments[i])
-					}
+// dispatch decodes a reassembled message and hands it to its handler.
+func (n *Node) dispatch(ctx context.Context, msg reassembled, meta Meta, pool *workerPool) {
+	full, err := n.open(msg)
+	if err != nil {
+		var de *dropError
+		if errors.As(err, &de) {
+			n.stats.inc(de.reason)
+		}
+		log.Printf("Dropped message %s: %v", msg.id, err)
+		return
+	}
 
-					var generic GenericMessage
-					if err := json.Unmarshal(full, &generic); err != nil {
-						log.Printf("Invalid generic message: %s", err)
-					} else {
-						handler, ok := handlerRegistry[generic.Type]
-						if !ok {
-							log.Printf("No handler for type: %s", generic.Type)
-						} else {
-							err := handler(generic.Payload, multicastaddr)
-							if err != nil {
-								log.Printf("Handler error: %s", err)
-							}
-						}
-					}
-				}
+	var generic GenericMessage
+	if err := json.Unmarshal(full, &generic); err != nil {
+		log.Printf("Invalid generic message: %s", err)
+		return
+	}
+	// 인증/복호화/디코딩이 끝난 메시지만 기록 (위조나 깨진 메시지가 정상 메시지를 막지 않도록)
+	if !n.dedup.first(msg.id, time.Now(), n.tuning.DedupWindow, n.tuning.DedupSize) {
+		n.stats.inc("dropped_duplicate")
+		return
+	}
 
-				delete(cache, frag.MessageID)
-			}
-			mu.Unlock()
+	meta.Type = generic.Type
+	meta.CorrelationID = generic.CorrelationID
+	switch generic.Type {
+	case replyMessageType:
+		n.handleReply(generic.Payload, meta)
+		return
+	case topicMessageType:
+		if err := n.handleTopic(generic.Payload, meta); err != nil {
+			log.Printf("Topic error: %s", err)
 		}
+		return
 	}
+
+	handler, ok := n.handler(generic.Type)
+	if !ok {
+		log.Printf("No handler for type: %s", generic.Type)
+		return
+	}
+
+	job := dispatchJob{ctx: ctx, handler: handler, payload: generic.Payload, meta: meta}
+	if pool == nil {
+		job.run(n.stats)
+		return
+	}
+	pool.submit(ctx, job)
 }
 
-func handleHostInfoSend(payload json.RawMessage, addr string) error {
+func (n *Node) handleHostInfoSend(ctx context.Context, payload json.RawMessage, meta Meta) error {
 	// trigger 기능만 수행
 	log.Println("✅ Received OK message (triggered)")
 
-	go SendWithEnvelope(addr, 1500, "hostinfo", payload)
-	return nil
-}
+	// 요청 ID 가 없으면 예전처럼 받은 payload 를 그대로 hostinfo 로 재전송
+	if meta.CorrelationID == "" {
+		go n.SendWithEnvelope(meta.Group, "hostinfo", payload)
+		return nil
+	}
 
-func handleHostInfo(payload json.RawMessage, addr string) error {
-	var info HostInfoReceiver
-	if err := json.Unmarshal(payload, &info); err != nil {
-		return fmt.Errorf("failed to decode host info: %w", err)
+	info, ok := n.localHostInfo()
+	if !ok {
+		return fmt.Errorf("local host info not initialized")
 	}
+	return n.Reply(ctx, meta, info)
+}
 
-	log.Printf("✅ Received full message from %s: %+v", info.Hostname, info.IPs)
+func (n *Node) localHostInfo() (HostInfoReceiver, bool) {
+	n.hostDataLock.RLock()
+	defer n.hostDataLock.RUnlock()
+	m, ok := n.hostData[n.hostname]
+	return m.HostInfoReceiver, ok
+}
 
-	hostDataLock.Lock()
-	defer hostDataLock.Unlock()
+func (n *Node) handleHostInfo(ctx context.Context, info HostInfoReceiver, meta Meta) error {
+	log.Printf("✅ Received full message from %s: %+v", info.Hostname, info.IPs)
 
-	existing, found := hostData[info.Hostname]
-	if !found || !equalIPs(existing.IPs, info.IPs) || existing.Endpoint != info.Endpoint || existing.EndpointPort != info.EndpointPort || existing.Version != info.Version || existing.BuildDate != info.BuildDate || existing.Revision != info.Revision {
-		hostData[info.Hostname] = info
+	seenAt := meta.ReceivedAt
+	if seenAt.IsZero() {
+		seenAt = time.Now()
+	}
+	if n.observe(info, seenAt) {
 		log.Printf("📥 Updated host data for %s", info.Hostname)
 	} else {
 		log.Printf("🧩 Duplicate host data for %s ignored", info.Hostname)
@@ -246,29 +352,12 @@ func equalIPs(a, b []string) bool {
 	return true
 }
 
-func GetHostData() map[string]HostInfoReceiver {
-	hostDataLock.RLock()
-	defer hostDataLock.RUnlock()
+func (n *Node) GetHostData() map[string]HostInfoReceiver {
+	n.hostDataLock.RLock()
+	defer n.hostDataLock.RUnlock()
 	copied := make(map[string]HostInfoReceiver)
-	for k, v := range hostData {
-		copied[k] = v
+	for k, v := range n.hostData {
+		copied[k] = v.HostInfoReceiver
 	}
 	return copied
 }
-
-func getLocalIPs() []string {
-	var ips []string
-	addrs, err := net.InterfaceAddrs()
-	if err != nil {
-		log.Printf("Failed to get interface addresses: %v", err)
-		return ips
-	}
-
-	for _, addr := range addrs {
-		if ipnet, ok := addr.(*net.IPNet); ok && !ipnet.IP.IsLoopback() && ipnet.IP.To4() != nil {
-			ips = append(ips, ipnet.String())
-		}
-	}
-
-	return ips
-}
@@ -0,0 +1,121 @@
+package multicast
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ReceiverError reports why the receiver on a single interface stopped.
+type ReceiverError struct {
+	Interface string
+	Err       error
+}
+
+func (e *ReceiverError) Error() string {
+	return fmt.Sprintf("receiver %s: %v", e.Interface, e.Err)
+}
+
+func (e *ReceiverError) Unwrap() error {
+	return e.Err
+}
+
+// Receivers is the handle for a set of per-interface receivers started by
+// Node.StartReceivers.
+type Receivers struct {
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	errCh  chan error
+	ifaces *interfaceSet
+
+	mu       sync.Mutex
+	errs     map[string]error // latest failure per receiver
+	errOrder []string
+}
+
+// minErrorBuffer is the smallest buffer of the Errors channel; receivers
+// started later by the interface watch report into the same channel.
+const minErrorBuffer = 16
+
+func newReceivers(cancel context.CancelFunc, size int) *Receivers {
+	return &Receivers{
+		cancel: cancel,
+		errCh:  make(chan error, max(size, minErrorBuffer)),
+		ifaces: newInterfaceSet(),
+		errs:   make(map[string]error),
+	}
+}
+
+func (r *Receivers) run(name string, fn func() error) {
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		if err := fn(); err != nil {
+			r.report(&ReceiverError{Interface: name, Err: err})
+		}
+	}()
+}
+
+// report records err as the latest failure of its receiver; a receiver that
+// keeps failing is reported once by Wait, with its most recent error.
+func (r *Receivers) report(err *ReceiverError) {
+	r.mu.Lock()
+	if _, ok := r.errs[err.Interface]; !ok {
+		r.errOrder = append(r.errOrder, err.Interface)
+	}
+	r.errs[err.Interface] = err
+	r.mu.Unlock()
+
+	select {
+	case r.errCh <- err:
+	default:
+	}
+}
+
+// closeWhenDone closes the error channel once every receiver has returned.
+func (r *Receivers) closeWhenDone() {
+	go func() {
+		r.wg.Wait()
+		close(r.errCh)
+	}()
+}
+
+// Errors delivers startup and runtime failures of individual receivers as they
+// happen. The channel is buffered (at least one slot per interface found at
+// startup); failures that do not fit because nobody reads it are not
+// delivered here, though Wait and Stop still return the latest failure of
+// every receiver. The channel is
+// closed after all receivers have stopped.
+func (r *Receivers) Errors() <-chan error {
+	return r.errCh
+}
+
+// Interfaces returns the names of the running per-interface receivers,
+// sorted.
+func (r *Receivers) Interfaces() []string {
+	names := r.ifaces.names()
+	sort.Strings(names)
+	return names
+}
+
+// Wait blocks until all receivers have stopped and returns their joined
+// errors, the latest one per receiver.
+func (r *Receivers) Wait() error {
+	r.wg.Wait()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	errs := make([]error, 0, len(r.errOrder))
+	for _, name := range r.errOrder {
+		errs = append(errs, r.errs[name])
+	}
+	return errors.Join(errs...)
+}
+
+// Stop cancels all receivers, closes their sockets and waits for them to exit.
+func (r *Receivers) Stop() error {
+	r.cancel()
+	return r.Wait()
+}
@@ -0,0 +1,139 @@
+package multicast
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestReceiversReportErrors(t *testing.T) {
+	_, cancel := context.WithCancel(context.Background())
+	r := newReceivers(cancel, 1)
+
+	failure := errors.New("listen failed")
+	r.run("eth0", func() error { return failure })
+	r.run("eth1", func() error { return nil })
+	r.closeWhenDone()
+
+	var got []error
+	for err := range r.Errors() {
+		got = append(got, err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("expected 1 error, got %v", got)
+	}
+	var rerr *ReceiverError
+	if !errors.As(got[0], &rerr) || rerr.Interface != "eth0" || !errors.Is(got[0], failure) {
+		t.Errorf("unexpected error %v", got[0])
+	}
+	if err := r.Wait(); !errors.Is(err, failure) {
+		t.Errorf("Wait returned %v", err)
+	}
+}
+
+func TestReceiversErrorOverflowKeptForWait(t *testing.T) {
+	_, cancel := context.WithCancel(context.Background())
+	r := newReceivers(cancel, 0)
+
+	for i := 0; i < minErrorBuffer+4; i++ {
+		r.report(&ReceiverError{Interface: fmt.Sprintf("eth%d", i), Err: errors.New("failure")})
+	}
+	if len(r.errCh) != minErrorBuffer {
+		t.Errorf("expected %d buffered errors, got %d", minErrorBuffer, len(r.errCh))
+	}
+	if n := len(r.errs); n != minErrorBuffer+4 {
+		t.Errorf("Wait would return %d errors, want %d", n, minErrorBuffer+4)
+	}
+
+	// 같은 수신기의 반복 실패는 마지막 것만 남김
+	latest := errors.New("latest")
+	r.report(&ReceiverError{Interface: "eth0", Err: latest})
+	if n := len(r.errs); n != minErrorBuffer+4 {
+		t.Errorf("repeated failure grew errors to %d", n)
+	}
+	if !errors.Is(r.errs["eth0"], latest) {
+		t.Errorf("eth0 kept %v, want the latest error", r.errs["eth0"])
+	}
+}
+
+func TestReceiversStopCancels(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := newReceivers(cancel, 1)
+	r.run("eth0", func() error {
+		<-ctx.Done()
+		return nil
+	})
+	r.closeWhenDone()
+
+	stopped := make(chan error)
+	go func() { stopped <- r.Stop() }()
+	select {
+	case err := <-stopped:
+		if err != nil {
+			t.Errorf("Stop returned %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Stop did not return")
+	}
+	if _, ok := <-r.Errors(); ok {
+		t.Error("Errors not closed after Stop")
+	}
+}
+
+func TestReceiveStopsWithCleanup(t *testing.T) {
+	addr, _ := net.ResolveUDPAddr("udp", "239.0.0.81:9981")
+	ifaces, err := groupInterfaces(addr, nil)
+	if err != nil || len(ifaces) == 0 {
+		t.Skip("no multicast interface available")
+	}
+	n := NewNode(WithReliable(ReliableConfig{}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := newReceivers(cancel, 1)
+	r.run(ifaces[0].Name, func() error {
+		return n.receive(ctx, addr, &ifaces[0], addr.String(), nil)
+	})
+	r.closeWhenDone()
+	time.Sleep(50 * time.Millisecond)
+
+	// 정리/NACK 고루틴까지 모두 끝나야 Stop 이 반환됨
+	stopped := make(chan error)
+	go func() { stopped <- r.Stop() }()
+	select {
+	case err := <-stopped:
+		if err != nil {
+			t.Errorf("Stop returned %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("receiver did not stop")
+	}
+}
+
+func TestReceiveListenFailureReported(t *testing.T) {
+	addr, _ := net.ResolveUDPAddr("udp", "239.0.0.81:9981")
+	missing := net.Interface{Index: 99999, Name: "missing0", Flags: net.FlagUp | net.FlagMulticast}
+	n := NewNode()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := newReceivers(cancel, 1)
+	r.run(missing.Name, func() error {
+		return n.receive(ctx, addr, &missing, addr.String(), nil)
+	})
+	r.closeWhenDone()
+
+	select {
+	case err := <-r.Errors():
+		var rerr *ReceiverError
+		if !errors.As(err, &rerr) || rerr.Interface != "missing0" {
+			t.Errorf("unexpected error %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("listen failure not reported")
+	}
+	if err := r.Wait(); err == nil {
+		t.Error("Wait returned no error")
+	}
+}
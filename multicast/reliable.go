@@ -0,0 +1,169 @@
+package multicast
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// nackMessageType is the reserved message type receivers use to request
+// retransmission of missing fragments.
+const nackMessageType = "_nack"
+
+// ReliableConfig enables NACK based delivery. Receivers multicast a NACK for
+// the sequence numbers still missing once a message has been quiet for
+// NACKDelay, and give up after MaxNACKs attempts. Senders keep the last
+// WindowSize messages (no older than Retention) to answer those NACKs.
+type ReliableConfig struct {
+	NACKDelay  time.Duration
+	MaxNACKs   int
+	WindowSize int
+	Retention  time.Duration
+}
+
+func DefaultReliableConfig() ReliableConfig {
+	return ReliableConfig{
+		NACKDelay:  200 * time.Millisecond,
+		MaxNACKs:   3,
+		WindowSize: 64,
+		Retention:  30 * time.Second,
+	}
+}
+
+// minNACKDelay is the shortest NACKDelay the node accepts; pending NACKs
+// are checked every NACKDelay/2.
+const minNACKDelay = time.Millisecond
+
+// WithReliable switches the node to NACK based delivery. Senders created
+// with Node.NewSender keep a retransmission window instead of blindly
+// repeating every fragment. Zero fields fall back to DefaultReliableConfig;
+// a NACKDelay below a millisecond is rejected by StartReceivers and
+// NewSender.
+func WithReliable(cfg ReliableConfig) Option {
+	defaults := DefaultReliableConfig()
+	if cfg.NACKDelay <= 0 {
+		cfg.NACKDelay = defaults.NACKDelay
+	}
+	if cfg.MaxNACKs <= 0 {
+		cfg.MaxNACKs = defaults.MaxNACKs
+	}
+	if cfg.WindowSize <= 0 {
+		cfg.WindowSize = defaults.WindowSize
+	}
+	if cfg.Retention <= 0 {
+		cfg.Retention = defaults.Retention
+	}
+	return func(n *Node) {
+		n.reliable = &cfg
+		n.window = newRetransmitWindow(cfg.WindowSize, cfg.Retention)
+	}
+}
+
+type nack struct {
+	MessageID string `json:"id"`
+	Missing   []int  `json:"missing"`
+}
+
+type windowEntry struct {
+	sender    *Sender
+	fragments [][]byte
+	sentAt    time.Time
+	resentAt  time.Time
+}
+
+// retransmitWindow is a bounded FIFO of recently sent messages.
+type retransmitWindow struct {
+	mu        sync.Mutex
+	size      int
+	retention time.Duration
+	order     []string
+	entries   map[string]*windowEntry
+}
+
+func newRetransmitWindow(size int, retention time.Duration) *retransmitWindow {
+	return &retransmitWindow{
+		size:      size,
+		retention: retention,
+		entries:   make(map[string]*windowEntry),
+	}
+}
+
+func (w *retransmitWindow) store(msgID string, s *Sender, fragments [][]byte) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.entries[msgID] = &windowEntry{sender: s, fragments: fragments, sentAt: time.Now()}
+	w.order = append(w.order, msgID)
+	for len(w.order) > w.size {
+		delete(w.entries, w.order[0])
+		w.order = w.order[1:]
+	}
+}
+
+// lookup returns the fragments for the requested sequence numbers. Requests
+// arriving within minGap of the previous retransmission are ignored so that
+// NACKs from many receivers for the same loss cause a single resend.
+func (w *retransmitWindow) lookup(n nack, minGap time.Duration) (*Sender, [][]byte) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	entry, ok := w.entries[n.MessageID]
+	if !ok {
+		return nil, nil
+	}
+	now := time.Now()
+	if w.retention > 0 && now.Sub(entry.sentAt) > w.retention {
+		return nil, nil
+	}
+	if now.Sub(entry.resentAt) < minGap {
+		return nil, nil
+	}
+	entry.resentAt = now
+
+	var fragments [][]byte
+	for _, seq := range n.Missing {
+		if seq >= 1 && seq <= len(entry.fragments) {
+			fragments = append(fragments, entry.fragments[seq-1])
+		}
+	}
+	return entry.sender, fragments
+}
+
+func withRetransmitWindow(w *retransmitWindow) SenderOption {
+	return func(s *Sender) {
+		s.window = w
+	}
+}
+
+func (n *Node) handleNACK(ctx context.Context, req nack, meta Meta) error {
+	s, fragments := n.window.lookup(req, n.reliable.NACKDelay/2)
+	if len(fragments) == 0 {
+		return nil
+	}
+
+	log.Printf("🔁 Retransmitting %d fragments of %s", len(fragments), req.MessageID)
+	go func() {
+		if err := s.retransmit(context.Background(), fragments); err != nil {
+			log.Printf("Retransmission of %s failed: %v", req.MessageID, err)
+		}
+	}()
+	return nil
+}
+
+// sendNACKs multicasts one NACK per incomplete message.
+func (n *Node) sendNACKs(addr string, nacks []nack) {
+	opts := append(append(n.senderTuning(), n.senderOptions()...), WithSendPolicy(SendOnce()))
+	s, err := NewSender(addr, n.mtu, opts...)
+	if err != nil {
+		log.Printf("Failed to create NACK sender: %v", err)
+		return
+	}
+
+	for _, req := range nacks {
+		log.Printf("Requesting %d missing fragments of %s", len(req.Missing), req.MessageID)
+		if err := s.Start(context.Background(), MessageEnvelope{Type: nackMessageType, Payload: req}); err != nil {
+			log.Printf("Failed to send NACK: %v", err)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package multicast
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestWithReliableDefaults(t *testing.T) {
+	n := NewNode(WithReliable(ReliableConfig{MaxNACKs: 5}))
+	if *n.reliable != (ReliableConfig{NACKDelay: 200 * time.Millisecond, MaxNACKs: 5, WindowSize: 64, Retention: 30 * time.Second}) {
+		t.Errorf("unexpected config %+v", *n.reliable)
+	}
+	if n.window.retention != 30*time.Second {
+		t.Errorf("window retention %s not defaulted", n.window.retention)
+	}
+}
+
+func TestNACKDelayValidated(t *testing.T) {
+	for _, delay := range []time.Duration{1, time.Microsecond} {
+		if err := NewNode(WithReliable(ReliableConfig{NACKDelay: delay})).validate(); err == nil {
+			t.Errorf("NACK delay %s accepted", delay)
+		}
+	}
+	if err := NewNode(WithReliable(ReliableConfig{NACKDelay: time.Millisecond})).validate(); err != nil {
+		t.Errorf("1ms NACK delay rejected: %v", err)
+	}
+}
+
+func TestRetransmitWindow(t *testing.T) {
+	w := newRetransmitWindow(2, time.Minute)
+	fragments := [][]byte{[]byte("1"), []byte("2"), []byte("3")}
+	w.store("a", nil, fragments)
+	w.store("b", nil, fragments)
+	w.store("c", nil, fragments)
+
+	if _, got := w.lookup(nack{MessageID: "a", Missing: []int{1}}, 0); got != nil {
+		t.Errorf("oldest message not evicted from full window")
+	}
+
+	_, got := w.lookup(nack{MessageID: "b", Missing: []int{0, 2, 3, 4}}, time.Second)
+	if len(got) != 2 || string(got[0]) != "2" || string(got[1]) != "3" {
+		t.Errorf("unexpected fragments %q", got)
+	}
+	// 다른 수신자의 같은 NACK 은 minGap 동안 무시
+	if _, got := w.lookup(nack{MessageID: "b", Missing: []int{2}}, time.Second); got != nil {
+		t.Errorf("repeated NACK within gap answered")
+	}
+
+	w.entries["c"].sentAt = time.Now().Add(-2 * time.Minute)
+	if _, got := w.lookup(nack{MessageID: "c", Missing: []int{1}}, 0); got != nil {
+		t.Errorf("message past retention retransmitted")
+	}
+}
+
+func TestPendingNACKsGiveUp(t *testing.T) {
+	stats := newCounters()
+	r := newReassembler(DefaultReassemblyLimits(), stats)
+	r.add(fragment("m", 2, 4, "x"), "peer")
+
+	cfg := ReliableConfig{MaxNACKs: 2}
+	for i := 0; i < 2; i++ {
+		nacks := r.pendingNACKs(cfg)
+		if len(nacks) != 1 || len(nacks[0].Missing) != 3 {
+			t.Fatalf("attempt %d: unexpected NACKs %v", i, nacks)
+		}
+	}
+	if nacks := r.pendingNACKs(cfg); len(nacks) != 0 {
+		t.Errorf("NACKs sent past MaxNACKs: %v", nacks)
+	}
+	if len(r.cache) != 0 || stats.snapshot()["evicted_nack_limit"] != 1 {
+		t.Errorf("message not given up: cache %d, stats %v", len(r.cache), stats.snapshot())
+	}
+}
+
+func TestHandleNACK(t *testing.T) {
+	n := NewNode(WithReliable(ReliableConfig{NACKDelay: time.Second}))
+	addr, _ := net.ResolveUDPAddr("udp", "239.0.0.1:9999")
+	// 전송 인터페이스가 없는 sender: 재전송이 네트워크로 나가지 않음
+	s := &Sender{addr: addr, ifaceFilter: &InterfaceFilter{Include: []string{"no-such-iface"}}}
+	n.window.store("m", s, [][]byte{[]byte("1"), []byte("2")})
+
+	if err := n.handleNACK(context.Background(), nack{MessageID: "unknown", Missing: []int{1}}, Meta{}); err != nil {
+		t.Fatalf("NACK for unknown message: %v", err)
+	}
+	if err := n.handleNACK(context.Background(), nack{MessageID: "m", Missing: []int{2}}, Meta{}); err != nil {
+		t.Fatalf("handleNACK failed: %v", err)
+	}
+
+	n.window.mu.Lock()
+	resent := n.window.entries["m"].resentAt
+	n.window.mu.Unlock()
+	if resent.IsZero() {
+		t.Errorf("NACK did not trigger a retransmission")
+	}
+}
@@ -0,0 +1,248 @@
+package multicast
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// replyMessageType is the reserved message type responders answer requests with.
+const replyMessageType = "_reply"
+
+// answeredRetention is how long a responder remembers the requests it has
+// answered, so repeated copies of a request are answered only once.
+const answeredRetention = time.Minute
+
+// Reply is one response to a Request.
+type Reply struct {
+	Payload json.RawMessage
+	Meta    Meta
+}
+
+type requestConfig struct {
+	maxReplies int
+}
+
+type RequestOption func(*requestConfig)
+
+// WithMaxReplies ends a request once max replies have been collected.
+func WithMaxReplies(max int) RequestOption {
+	return func(c *requestConfig) {
+		c.maxReplies = max
+	}
+}
+
+// pendingRequest collects the replies to one outstanding request.
+type pendingRequest struct {
+	replies    chan Reply
+	full       chan struct{}
+	mu         sync.Mutex
+	seen       map[string]struct{}
+	count      int
+	maxReplies int
+	closed     bool
+}
+
+// deliver queues reply without blocking, since it runs on the receiver's
+// read loop. It reports false if the reply was dropped because the caller
+// does not keep up.
+func (p *pendingRequest) deliver(reply Reply) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed || (p.maxReplies > 0 && p.count >= p.maxReplies) {
+		return true
+	}
+	// 같은 응답이 여러 인터페이스/반복 전송으로 중복 수신될 수 있음
+	if _, ok := p.seen[reply.Meta.MessageID]; ok {
+		return true
+	}
+
+	select {
+	case p.replies <- reply:
+	default:
+		return false
+	}
+	p.seen[reply.Meta.MessageID] = struct{}{}
+
+	p.count++
+	if p.maxReplies > 0 && p.count == p.maxReplies {
+		close(p.full)
+	}
+	return true
+}
+
+func (p *pendingRequest) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	close(p.replies)
+}
+
+// requestTable tracks the requests a node is waiting on and the requests it
+// has already answered.
+type requestTable struct {
+	mu       sync.Mutex
+	pending  map[string]*pendingRequest
+	answered map[string]time.Time
+}
+
+func newRequestTable() *requestTable {
+	return &requestTable{
+		pending:  make(map[string]*pendingRequest),
+		answered: make(map[string]time.Time),
+	}
+}
+
+func (t *requestTable) lookup(id string) (*pendingRequest, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	p, ok := t.pending[id]
+	return p, ok
+}
+
+// markAnswered records id and reports whether it was answered before.
+func (t *requestTable) markAnswered(id string, now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for answered, at := range t.answered {
+		if now.Sub(at) > answeredRetention {
+			delete(t.answered, answered)
+		}
+	}
+	if _, ok := t.answered[id]; ok {
+		return true
+	}
+	t.answered[id] = now
+	return false
+}
+
+func Request(ctx context.Context, msgType string, payload any, opts ...RequestOption) ([]Reply, error) {
+	return defaultNode.Request(ctx, msgType, payload, opts...)
+}
+
+// Request sends payload as msgType to the node's group and collects replies
+// until ctx is done or WithMaxReplies is reached. Replies are only received
+// while the node's receivers are running.
+func (n *Node) Request(ctx context.Context, msgType string, payload any, opts ...RequestOption) ([]Reply, error) {
+	ch, err := n.RequestChan(ctx, msgType, payload, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var replies []Reply
+	for reply := range ch {
+		replies = append(replies, reply)
+	}
+	return replies, nil
+}
+
+// RequestChan is like Request but streams replies as they arrive. The channel
+// is closed when ctx is done or WithMaxReplies is reached. It buffers 16
+// replies; further replies are dropped while the caller falls behind.
+func (n *Node) RequestChan(ctx context.Context, msgType string, payload any, opts ...RequestOption) (<-chan Reply, error) {
+	cfg := requestConfig{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	group := n.Group()
+	if group == "" {
+		return nil, fmt.Errorf("no multicast group configured")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	id := newMessageID()
+	p := &pendingRequest{
+		replies:    make(chan Reply, 16),
+		full:       make(chan struct{}),
+		seen:       make(map[string]struct{}),
+		maxReplies: cfg.maxReplies,
+	}
+
+	n.requests.mu.Lock()
+	n.requests.pending[id] = p
+	n.requests.mu.Unlock()
+
+	finish := func() {
+		cancel()
+		n.requests.mu.Lock()
+		delete(n.requests.pending, id)
+		n.requests.mu.Unlock()
+		p.close()
+	}
+
+	err := n.sendEnvelope(ctx, group, MessageEnvelope{
+		Type:          msgType,
+		Payload:       payload,
+		CorrelationID: id,
+	})
+	if err != nil {
+		finish()
+		return nil, err
+	}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-p.full:
+		}
+		finish()
+	}()
+	return p.replies, nil
+}
+
+// Reply answers the request meta was received with. Repeated copies of the
+// same request are answered only once.
+func (n *Node) Reply(ctx context.Context, meta Meta, payload any) error {
+	if meta.CorrelationID == "" {
+		return fmt.Errorf("message %s is not a request", meta.MessageID)
+	}
+	if n.requests.markAnswered(meta.CorrelationID, time.Now()) {
+		return nil
+	}
+	return n.sendEnvelope(ctx, meta.Group, MessageEnvelope{
+		Type:          replyMessageType,
+		Payload:       payload,
+		CorrelationID: meta.CorrelationID,
+	})
+}
+
+func (n *Node) handleReply(payload json.RawMessage, meta Meta) {
+	p, ok := n.requests.lookup(meta.CorrelationID)
+	if !ok {
+		return
+	}
+	if !p.deliver(Reply{Payload: payload, Meta: meta}) {
+		n.stats.inc("dropped_reply_overflow")
+	}
+}
+
+func RequestHostInfo(ctx context.Context) ([]HostInfoReceiver, error) {
+	return defaultNode.RequestHostInfo(ctx)
+}
+
+// RequestHostInfo asks every node in the group for its host info and returns
+// the answers received before ctx is done.
+func (n *Node) RequestHostInfo(ctx context.Context) ([]HostInfoReceiver, error) {
+	replies, err := n.Request(ctx, "hostinfoSend", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]HostInfoReceiver, 0, len(replies))
+	for _, reply := range replies {
+		var info HostInfoReceiver
+		if err := json.Unmarshal(reply.Payload, &info); err != nil {
+			continue
+		}
+		if err := info.Validate(); err != nil {
+			continue
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
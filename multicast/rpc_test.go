@@ -0,0 +1,195 @@
+package multicast
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestReplyDeliveryDeduplicates(t *testing.T) {
+	n := NewNode()
+	p := &pendingRequest{
+		replies:    make(chan Reply, 4),
+		full:       make(chan struct{}),
+		seen:       make(map[string]struct{}),
+		maxReplies: 2,
+	}
+	n.requests.pending["req"] = p
+
+	payload := json.RawMessage(`{}`)
+	n.handleReply(payload, Meta{MessageID: "r1", CorrelationID: "req"})
+	n.handleReply(payload, Meta{MessageID: "r1", CorrelationID: "req"})
+	n.handleReply(payload, Meta{MessageID: "r2", CorrelationID: "other"})
+	if len(p.replies) != 1 {
+		t.Fatalf("expected 1 reply, got %d", len(p.replies))
+	}
+
+	n.handleReply(payload, Meta{MessageID: "r3", CorrelationID: "req"})
+	select {
+	case <-p.full:
+	default:
+		t.Errorf("expected request to be full after max replies")
+	}
+}
+
+func TestRequestAnsweredOnce(t *testing.T) {
+	table := newRequestTable()
+	now := time.Now()
+
+	if table.markAnswered("req", now) {
+		t.Fatalf("first answer reported as repeat")
+	}
+	if !table.markAnswered("req", now.Add(time.Second)) {
+		t.Errorf("repeated request answered twice")
+	}
+	if table.markAnswered("req", now.Add(2*answeredRetention)) {
+		t.Errorf("expected answer to be forgotten after retention")
+	}
+}
+
+// requestNode returns a node whose requests are answered by replies instead
+// of going out on the network.
+func requestNode(replies ...string) *Node {
+	n := NewNode(WithGroup("239.0.0.1:9999"))
+	n.send = func(ctx context.Context, addr string, env MessageEnvelope) error {
+		go func() {
+			for i, reply := range replies {
+				n.handleReply(json.RawMessage(reply), Meta{
+					MessageID:     fmt.Sprintf("reply-%d", i),
+					CorrelationID: env.CorrelationID,
+				})
+			}
+		}()
+		return nil
+	}
+	return n
+}
+
+func TestRequestCollectsReplies(t *testing.T) {
+	n := requestNode(`{"hostname":"a"}`, `{"hostname":"b"}`, `{"hostname":"c"}`)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	replies, err := n.Request(ctx, "ping", nil, WithMaxReplies(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(replies) != 2 {
+		t.Fatalf("expected 2 replies, got %d", len(replies))
+	}
+	if ctx.Err() != nil {
+		t.Errorf("request did not end at max replies")
+	}
+
+	n.requests.mu.Lock()
+	pending := len(n.requests.pending)
+	n.requests.mu.Unlock()
+	if pending != 0 {
+		t.Errorf("finished request still pending")
+	}
+}
+
+func TestRequestChanSlowReaderDoesNotBlock(t *testing.T) {
+	replies := make([]string, 20)
+	for i := range replies {
+		replies[i] = "{}"
+	}
+	n := requestNode()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch, err := n.RequestChan(ctx, "ping", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var id string
+	n.requests.mu.Lock()
+	for id = range n.requests.pending {
+	}
+	n.requests.mu.Unlock()
+
+	// 읽지 않는 동안에도 수신 루프는 막히지 않아야 함
+	done := make(chan struct{})
+	go func() {
+		for i := range replies {
+			n.handleReply(json.RawMessage(replies[i]), Meta{MessageID: fmt.Sprintf("r%d", i), CorrelationID: id})
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("handleReply blocked on a slow reader")
+	}
+
+	if got := n.Stats()["dropped_reply_overflow"]; got != 4 {
+		t.Errorf("dropped_reply_overflow = %d, want 4", got)
+	}
+	cancel()
+	count := 0
+	for range ch {
+		count++
+	}
+	if count != 16 {
+		t.Errorf("expected 16 buffered replies, got %d", count)
+	}
+}
+
+func TestHostInfoSendReplies(t *testing.T) {
+	n := NewNode(WithHostname("h1"), WithGroup("239.0.0.1:9999"))
+	n.Init()
+	sent := make(chan MessageEnvelope, 4)
+	n.send = func(ctx context.Context, addr string, env MessageEnvelope) error {
+		sent <- env
+		return nil
+	}
+	next := func() MessageEnvelope {
+		t.Helper()
+		select {
+		case env := <-sent:
+			return env
+		case <-time.After(time.Second):
+			t.Fatal("nothing sent")
+			return MessageEnvelope{}
+		}
+	}
+
+	// 요청 ID 가 없으면 받은 payload 를 그대로 재전송
+	payload := json.RawMessage(`{"hostname":"peer"}`)
+	if err := n.handleHostInfoSend(context.Background(), payload, Meta{Group: n.Group()}); err != nil {
+		t.Fatal(err)
+	}
+	env := next()
+	if raw, ok := env.Payload.(json.RawMessage); env.Type != "hostinfo" || !ok || string(raw) != string(payload) {
+		t.Errorf("uncorrelated trigger sent %s %v", env.Type, env.Payload)
+	}
+
+	if err := n.handleHostInfoSend(context.Background(), payload, Meta{Group: n.Group(), CorrelationID: "req"}); err != nil {
+		t.Fatal(err)
+	}
+	env = next()
+	if info, ok := env.Payload.(HostInfoReceiver); env.Type != replyMessageType || env.CorrelationID != "req" || !ok || info.Hostname != "h1" {
+		t.Errorf("request answered with %s %+v", env.Type, env.Payload)
+	}
+}
+
+func TestReplyUsesContext(t *testing.T) {
+	n := NewNode()
+	var got context.Context
+	n.send = func(ctx context.Context, addr string, env MessageEnvelope) error {
+		got = ctx
+		return ctx.Err()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := n.Reply(ctx, Meta{MessageID: "m", CorrelationID: "req"}, "pong"); err == nil {
+		t.Error("reply sent on a cancelled context")
+	}
+	if got != ctx {
+		t.Error("reply not sent with the caller's context")
+	}
+}
@@ -0,0 +1,184 @@
+package multicast
+
+import (
+	"fmt"
+
+	"github.com/swlee3306/common-sdk/compression"
+	"github.com/swlee3306/common-sdk/encryption"
+)
+
+// Message flags carried in every fragment header.
+const (
+	// FlagEncrypted marks a message body sealed with an encryption.Encryptor.
+	FlagEncrypted uint8 = 1 << iota
+	// FlagGzip marks a message body compressed with gzip.
+	FlagGzip
+	// FlagLZ4 marks a message body compressed with LZ4.
+	FlagLZ4
+	// FlagAuthenticated marks a message body framed with a timestamp, nonce and HMAC.
+	FlagAuthenticated
+
+	compressionFlags = FlagGzip | FlagLZ4
+)
+
+func compressionFlag(algorithm compression.Algorithm) (uint8, error) {
+	switch algorithm {
+	case compression.Gzip:
+		return FlagGzip, nil
+	case compression.LZ4:
+		return FlagLZ4, nil
+	default:
+		return 0, fmt.Errorf("unsupported algorithm: %s", algorithm)
+	}
+}
+
+func compressionAlgorithm(flags uint8) (compression.Algorithm, error) {
+	switch flags & compressionFlags {
+	case FlagGzip:
+		return compression.Gzip, nil
+	case FlagLZ4:
+		return compression.LZ4, nil
+	default:
+		return "", fmt.Errorf("invalid compression flags %#x", flags&compressionFlags)
+	}
+}
+
+// compressionConfig compresses bodies of at least threshold bytes.
+type compressionConfig struct {
+	algorithm compression.Algorithm
+	threshold int
+}
+
+// WithCompressor compresses message bodies of at least threshold bytes with
+// algorithm before fragmentation. Bodies that do not shrink are sent as is.
+func WithCompressor(algorithm compression.Algorithm, threshold int) SenderOption {
+	return func(s *Sender) {
+		s.compression = &compressionConfig{algorithm: algorithm, threshold: threshold}
+	}
+}
+
+// WithCompression makes every sender of the node compress message bodies of
+// at least threshold bytes. Receivers decompress automatically.
+func WithCompression(algorithm compression.Algorithm, threshold int) Option {
+	return func(n *Node) {
+		n.compression = &compressionConfig{algorithm: algorithm, threshold: threshold}
+	}
+}
+
+// WithEncryptor encrypts every message body before it is fragmented.
+func WithEncryptor(enc *encryption.Encryptor) SenderOption {
+	return func(s *Sender) {
+		s.encryptor = enc
+	}
+}
+
+// WithEncryption makes the node encrypt everything it sends with enc and
+// decrypt incoming messages flagged as encrypted. Plaintext messages are
+// dropped unless WithPlaintextAllowed is also given.
+func WithEncryption(enc *encryption.Encryptor) Option {
+	return func(n *Node) {
+		n.encryptor = enc
+	}
+}
+
+// WithPlaintextAllowed accepts unencrypted messages on a node that has
+// encryption enabled, e.g. while a fleet is being migrated.
+func WithPlaintextAllowed() Option {
+	return func(n *Node) {
+		n.allowPlaintext = true
+	}
+}
+
+// seal applies the sender's body transformations and returns the resulting
+// header flags. Compression runs first since ciphertext does not compress;
+// the MAC is computed last so receivers can reject forgeries cheaply.
+func (s *Sender) seal(body []byte) (uint8, []byte, error) {
+	var flags uint8
+
+	if c := s.compression; c != nil && len(body) >= c.threshold {
+		flag, err := compressionFlag(c.algorithm)
+		if err != nil {
+			return 0, nil, err
+		}
+		compressed, err := compression.NewCompressor(c.algorithm).Compress(body)
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to compress message: %w", err)
+		}
+		if len(compressed) < len(body) {
+			body = compressed
+			flags |= flag
+		}
+	}
+
+	if s.encryptor != nil {
+		encrypted, err := s.encryptor.Encrypt(body)
+		if err != nil {
+			return 0, nil, err
+		}
+		body = encrypted
+		flags |= FlagEncrypted
+	}
+
+	if s.authKey != nil {
+		flags |= FlagAuthenticated
+		authenticated, err := authenticate(s.authKey, flags, body)
+		if err != nil {
+			return 0, nil, err
+		}
+		body = authenticated
+	}
+
+	return flags, body, nil
+}
+
+// open reverses seal on a reassembled message. Messages that cannot be
+// opened are reported as drops.
+func (n *Node) open(msg reassembled) ([]byte, error) {
+	body := msg.body
+
+	if msg.flags&FlagAuthenticated != 0 {
+		if n.authKey != nil {
+			verified, err := n.verifyAuth(msg)
+			if err != nil {
+				return nil, err
+			}
+			body = verified
+		} else {
+			frame, _, err := parseAuthFrame(body)
+			if err != nil {
+				return nil, drop("rejected_malformed", err)
+			}
+			body = frame.body
+		}
+	} else if n.authKey != nil {
+		return nil, drop("rejected_unauthenticated", nil)
+	}
+
+	if msg.flags&FlagEncrypted != 0 {
+		if n.encryptor == nil {
+			return nil, drop("dropped_encrypted_no_key", nil)
+		}
+		decrypted, err := n.encryptor.Decrypt(body)
+		if err != nil {
+			return nil, drop("dropped_decrypt_failed", err)
+		}
+		body = decrypted
+	} else if n.encryptor != nil && !n.allowPlaintext {
+		return nil, drop("dropped_plaintext", nil)
+	}
+
+	if msg.flags&compressionFlags != 0 {
+		algorithm, err := compressionAlgorithm(msg.flags)
+		if err != nil {
+			return nil, drop("dropped_decompress_failed", err)
+		}
+		// 압축 폭탄 방지: 메시지 크기 제한까지만 해제
+		decompressed, err := compression.NewCompressor(algorithm).DecompressLimit(body, n.reassemblyLimits.MaxMessageBytes)
+		if err != nil {
+			return nil, drop("dropped_decompress_failed", err)
+		}
+		body = decompressed
+	}
+
+	return body, nil
+}
@@ -0,0 +1,73 @@
+package multicast
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/swlee3306/common-sdk/compression"
+)
+
+func TestCompressionRoundTrip(t *testing.T) {
+	body := `{"type":"hostinfo","payload":"` + string(bytes.Repeat([]byte("a"), 1000)) + `"}`
+
+	for _, algorithm := range []compression.Algorithm{compression.Gzip, compression.LZ4} {
+		s := &Sender{compression: &compressionConfig{algorithm: algorithm, threshold: 100}}
+		msg := sealForTest(t, s, body)
+		if msg.flags&compressionFlags == 0 {
+			t.Fatalf("%s: body was not compressed", algorithm)
+		}
+		if len(msg.body) >= len(body) {
+			t.Errorf("%s: compressed body is %d bytes, original %d", algorithm, len(msg.body), len(body))
+		}
+
+		opened, err := NewNode().open(msg)
+		if err != nil {
+			t.Fatalf("%s: open failed: %v", algorithm, err)
+		}
+		if string(opened) != body {
+			t.Errorf("%s: round trip changed the body", algorithm)
+		}
+	}
+}
+
+func TestCompressionSkipsSmallBodies(t *testing.T) {
+	s := &Sender{compression: &compressionConfig{algorithm: compression.Gzip, threshold: 1024}}
+	msg := sealForTest(t, s, `{"type":"hostinfo"}`)
+	if msg.flags != 0 || string(msg.body) != `{"type":"hostinfo"}` {
+		t.Errorf("body below threshold was transformed: flags %#x", msg.flags)
+	}
+
+	// 압축해도 줄지 않으면 원문 그대로
+	s.compression.threshold = 0
+	msg = sealForTest(t, s, `{}`)
+	if msg.flags != 0 {
+		t.Errorf("incompressible body was flagged %#x", msg.flags)
+	}
+}
+
+func TestCompressionWrongFlagDropped(t *testing.T) {
+	n := NewNode()
+
+	msg := reassembled{id: "m", flags: FlagGzip, body: []byte(`{"type":"hostinfo"}`)}
+	if _, err := n.open(msg); dropReason(err) != "dropped_decompress_failed" {
+		t.Errorf("plain body flagged gzip: %v", err)
+	}
+
+	msg = reassembled{id: "m", flags: FlagGzip | FlagLZ4, body: []byte(`{}`)}
+	if _, err := n.open(msg); dropReason(err) != "dropped_decompress_failed" {
+		t.Errorf("both compression flags: %v", err)
+	}
+}
+
+func TestCompressionBombDropped(t *testing.T) {
+	n := NewNode(WithReassemblyLimits(ReassemblyLimits{MaxMessageBytes: 64 << 10}))
+	s := &Sender{compression: &compressionConfig{algorithm: compression.Gzip}}
+
+	msg := sealForTest(t, s, string(bytes.Repeat([]byte("a"), 1<<20)))
+	if len(msg.body) > 64<<10 {
+		t.Fatalf("compressed bomb is %d bytes", len(msg.body))
+	}
+	if _, err := n.open(msg); dropReason(err) != "dropped_decompress_failed" {
+		t.Errorf("expected dropped_decompress_failed, got %v", err)
+	}
+}
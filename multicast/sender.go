@@ -3,371 +3,393 @@ package multicast
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
-	"math"
 	"net"
 	"os"
-	"reflect"
+	"sync"
 	"time"
 
-	"golang.org/x/net/ipv4"
+	"github.com/swlee3306/common-sdk/encryption"
 )
 
 type MessageEnvelope struct {
 	Type    string      `json:"type"`
 	Payload interface{} `json:"payload"`
+	// CorrelationID ties a reply to the request it answers.
+	CorrelationID string `json:"correlationId,omitempty"`
 }
 
-func SendWithEnvelope(addr string, mtu int, typ string, payload interface{}) error {
-	return RunFragmentedSender(addr, mtu, MessageEnvelope{
-		Type:    typ,
-		Payload: payload,
-	})
+// SendPolicy controls how often a message is put on the wire.
+type SendPolicy struct {
+	// Repeats is the number of passes over all fragments per round.
+	Repeats int
+	// Interval, when set, repeats the round until the context is cancelled.
+	Interval time.Duration
+	// FragmentGap is the pause after each fragment.
+	FragmentGap time.Duration
+	// RepeatGap is the pause after each pass.
+	RepeatGap time.Duration
 }
 
-// RunFragmentedSenderRequest sends a fragmented request message over UDP using multiple interfaces. (한번만 전송)
-func RunFragmentedSender(addr string, mtu int, data any) error {
-	hostname, _ := os.Hostname()
-	msgID := fmt.Sprintf("%s-%s-%d", reflect.TypeOf(data).Name(), hostname, time.Now().UnixNano())
+// SendOnce transmits every fragment a single time.
+func SendOnce() SendPolicy {
+	return SendPolicy{Repeats: 1, FragmentGap: 10 * time.Millisecond}
+}
 
-	msgBytes, err := json.Marshal(data)
-	if err != nil {
-		return fmt.Errorf("invalid data for marshalling: %w", err)
+// SendRepeated transmits every fragment n times to survive packet loss.
+func SendRepeated(n int) SendPolicy {
+	return SendPolicy{Repeats: n, FragmentGap: 10 * time.Millisecond, RepeatGap: 300 * time.Millisecond}
+}
+
+// minSendInterval is the shortest interval a periodic send accepts.
+const minSendInterval = time.Millisecond
+
+// defaultCicleInterval is what RunFragmentedSenderCicle always used before it
+// honoured its interval argument.
+const defaultCicleInterval = 2 * time.Second
+
+// SendPeriodic transmits the message once every interval until cancelled.
+// Intervals below a millisecond are rejected by NewSender.
+func SendPeriodic(interval time.Duration) SendPolicy {
+	return SendPolicy{Repeats: 1, Interval: interval, FragmentGap: 10 * time.Millisecond}
+}
+
+// Sender is the single engine behind every fragmented send: it serializes a
+// message, splits it with its Fragmenter and transmits it on all multicast
+// interfaces according to its SendPolicy.
+type Sender struct {
+	addr        *net.UDPAddr
+	fragmenter  Fragmenter
+	policy      SendPolicy
+	hopLimit    int
+	loopback    bool
+	sendBuffer  int
+	ifaceFilter *InterfaceFilter
+	redundancy  float64
+	encryptor   *encryption.Encryptor
+	compression *compressionConfig
+	authKey     []byte
+	window      *retransmitWindow
+
+	// interfaces resolves the interfaces of each round; tests replace it.
+	interfaces func(group *net.UDPAddr, filter *InterfaceFilter) ([]net.Interface, error)
+}
+
+type SenderOption func(*Sender)
+
+func WithFragmenter(f Fragmenter) SenderOption {
+	return func(s *Sender) {
+		s.fragmenter = f
 	}
+}
 
-	maxPayloadSize := mtu - 100
-	totalFragments := int(math.Ceil(float64(len(msgBytes)) / float64(maxPayloadSize)))
+func WithSendPolicy(p SendPolicy) SenderOption {
+	return func(s *Sender) {
+		s.policy = p
+	}
+}
 
-	type Fragment struct {
-		MessageID string `json:"id"`
-		Seq       int    `json:"seq"`
-		Total     int    `json:"total"`
-		Data      []byte `json:"data"`
+// WithHopLimit sets the multicast TTL (IPv4) or hop limit (IPv6) of sent datagrams.
+func WithHopLimit(hops int) SenderOption {
+	return func(s *Sender) {
+		s.hopLimit = hops
 	}
+}
 
-	var fragments [][]byte
-	for i := 0; i < totalFragments; i++ {
-		start := i * maxPayloadSize
-		end := start + maxPayloadSize
-		if end > len(msgBytes) {
-			end = len(msgBytes)
-		}
+// WithMulticastLoopback enables or disables delivery of sent datagrams to
+// receivers on the same host (enabled by default).
+func WithMulticastLoopback(enabled bool) SenderOption {
+	return func(s *Sender) {
+		s.loopback = enabled
+	}
+}
 
-		fragment := Fragment{
-			MessageID: msgID,
-			Seq:       i + 1,
-			Total:     totalFragments,
-			Data:      msgBytes[start:end],
-		}
+// WithSendBuffer sets SO_SNDBUF of the sending sockets.
+func WithSendBuffer(bytes int) SenderOption {
+	return func(s *Sender) {
+		s.sendBuffer = bytes
+	}
+}
 
-		j, err := json.Marshal(fragment)
-		if err != nil {
-			return fmt.Errorf("failed to marshal fragment %d: %w", i+1, err)
-		}
-		fragments = append(fragments, j)
+// WithRedundancy adds ceil(fragments*ratio) forward error correction parity
+// fragments to every message, so receivers can rebuild it from any K of the
+// N fragments without talking back.
+func WithRedundancy(ratio float64) SenderOption {
+	return func(s *Sender) {
+		s.redundancy = ratio
 	}
+}
 
+func NewSender(addr string, mtu int, opts ...SenderOption) (*Sender, error) {
 	udpAddr, err := net.ResolveUDPAddr("udp", addr)
 	if err != nil {
-		return fmt.Errorf("failed to resolve address %s: %w", addr, err)
+		return nil, fmt.Errorf("failed to resolve address %s: %w", addr, err)
 	}
 
-	ifaces, err := net.Interfaces()
-	if err != nil {
-		return fmt.Errorf("failed to list interfaces: %w", err)
+	s := &Sender{
+		addr:       udpAddr,
+		fragmenter: NewJSONFragmenter(mtu),
+		policy:     SendOnce(),
+		loopback:   true,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.policy.Interval < 0 || (s.policy.Interval > 0 && s.policy.Interval < minSendInterval) {
+		return nil, fmt.Errorf("send interval %s must be at least %s", s.policy.Interval, minSendInterval)
 	}
 
-	for _, iface := range ifaces {
-		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagMulticast == 0 {
-			continue
-		}
-
-		addrs, _ := iface.Addrs()
-		hasIPv4 := false
-		for _, addr := range addrs {
-			if ipnet, ok := addr.(*net.IPNet); ok && ipnet.IP.To4() != nil {
-				hasIPv4 = true
-				break
-			}
-		}
-		if !hasIPv4 {
-			continue
+	if s.redundancy > 0 {
+		f, ok := s.fragmenter.(interface {
+			withRedundancy(ratio float64) Fragmenter
+		})
+		if !ok {
+			return nil, fmt.Errorf("fragmenter %T does not support redundancy", s.fragmenter)
 		}
+		s.fragmenter = f.withRedundancy(s.redundancy)
+	}
+	return s, nil
+}
 
-		log.Printf("Sending fragmented message via interface: %s", iface.Name)
-
-		go func(iface net.Interface, fragments [][]byte) {
-			conn, err := net.ListenPacket("udp4", "")
-			if err != nil {
-				log.Printf("[%s] failed to create UDP socket: %v", iface.Name, err)
-				return
-			}
-			defer conn.Close()
-
-			p := ipv4.NewPacketConn(conn)
-			if err := p.SetMulticastInterface(&iface); err != nil {
-				log.Printf("[%s] failed to set multicast interface: %v", iface.Name, err)
-				return
-			}
+// Send transmits data and blocks until the send policy is complete or ctx is
+// cancelled.
+func (s *Sender) Send(ctx context.Context, data any) error {
+	msg, err := s.prepare(data)
+	if err != nil {
+		return err
+	}
+	return s.send(ctx, msg)
+}
 
-			// 메시지를 한 번만 전송
-			select {
-			default:
-				for i := 0; i < 3; i++ {
-					for _, fragment := range fragments {
-						_, err := p.WriteTo(fragment, nil, udpAddr)
-						time.Sleep(10 * time.Millisecond)
-						if err != nil {
-							log.Printf("[%s] send fragment failed: %v", iface.Name, err)
-						} else {
-							log.Printf("[%s] sent fragment: %s", iface.Name, fragment)
-						}
-						time.Sleep(300 * time.Millisecond)
-					}
-				}
-			}
-		}(iface, fragments)
+// Start validates data synchronously and transmits it in the background.
+func (s *Sender) Start(ctx context.Context, data any) error {
+	msg, err := s.prepare(data)
+	if err != nil {
+		return err
 	}
 
+	go func() {
+		if err := s.send(ctx, msg); err != nil {
+			log.Printf("Send failed: %v", err)
+		}
+	}()
 	return nil
 }
 
-// RunFragmentedSender sends a fragmented message over UDP using multiple interfaces. (반복적으로 전송 특정 초 입력)
-func RunFragmentedSenderCicle(addr string, mtu int, data any, second time.Duration) error {
-	hostname, _ := os.Hostname()
-	msgID := fmt.Sprintf("%s-%d", hostname, time.Now().UnixNano())
+// sealedMessage is a serialized message and its sealed body.
+type sealedMessage struct {
+	raw   []byte
+	flags uint8
+	body  []byte
+}
 
-	ifaces, err := net.Interfaces()
+// prepare serializes data and seals the resulting message body.
+func (s *Sender) prepare(data any) (sealedMessage, error) {
+	msgBytes, err := json.Marshal(data)
 	if err != nil {
-		return fmt.Errorf("failed to list interfaces: %w", err)
+		return sealedMessage{}, fmt.Errorf("invalid data for marshalling: %w", err)
 	}
+	return s.reseal(msgBytes)
+}
 
-	msgBytes, err := json.Marshal(data)
+// reseal seals raw again. Every round of a periodic send needs its own
+// timestamp and nonce, or authenticated receivers reject it as a replay.
+func (s *Sender) reseal(raw []byte) (sealedMessage, error) {
+	flags, body, err := s.seal(raw)
 	if err != nil {
-		return fmt.Errorf("invalid data for marshalling: %w", err)
+		return sealedMessage{}, fmt.Errorf("failed to seal message: %w", err)
 	}
+	return sealedMessage{raw: raw, flags: flags, body: body}, nil
+}
 
-	maxPayloadSize := mtu - 100
-	totalFragments := int(math.Ceil(float64(len(msgBytes)) / float64(maxPayloadSize)))
-
-	type Fragment struct {
-		MessageID string `json:"id"`
-		Seq       int    `json:"seq"`
-		Total     int    `json:"total"`
-		Data      []byte `json:"data"`
+func (s *Sender) send(ctx context.Context, msg sealedMessage) error {
+	if s.policy.Interval <= 0 {
+		return s.sendRound(ctx, msg.flags, msg.body)
 	}
 
-	var fragments [][]byte
-	for i := 0; i < totalFragments; i++ {
-		start := i * maxPayloadSize
-		end := start + maxPayloadSize
-		if end > len(msgBytes) {
-			end = len(msgBytes)
+	ticker := time.NewTicker(s.policy.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.sendRound(ctx, msg.flags, msg.body); err != nil {
+			log.Printf("Periodic send failed: %v", err)
 		}
 
-		fragment := Fragment{
-			MessageID: msgID,
-			Seq:       i + 1,
-			Total:     totalFragments,
-			Data:      msgBytes[start:end],
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
 		}
 
-		j, err := json.Marshal(fragment)
-		if err != nil {
-			return fmt.Errorf("failed to marshal fragment %d: %w", i+1, err)
+		var err error
+		if msg, err = s.reseal(msg.raw); err != nil {
+			return err
 		}
-		fragments = append(fragments, j)
 	}
+}
 
-	udpAddr, err := net.ResolveUDPAddr("udp", addr)
+// sendRound fragments the message under a fresh message ID and transmits it
+// on every interface in parallel. Interfaces are looked up for every round so
+// periodic sends follow NICs that come and go.
+func (s *Sender) sendRound(ctx context.Context, flags uint8, body []byte) error {
+	ifaces, err := s.groupInterfaces()
 	if err != nil {
-		return fmt.Errorf("failed to resolve address %s: %w", addr, err)
+		return err
+	}
+	msgID := newMessageID()
+	fragments, err := s.fragmenter.Fragment(msgID, flags, body)
+	if err != nil {
+		return err
+	}
+	if s.window != nil {
+		s.window.store(msgID, s, fragments)
 	}
 
-	for _, iface := range ifaces {
-		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagMulticast == 0 {
-			continue
-		}
+	return s.transmitAll(ctx, ifaces, fragments, s.policy.Repeats)
+}
 
-		addrs, _ := iface.Addrs()
-		hasIPv4 := false
-		for _, addr := range addrs {
-			if ipnet, ok := addr.(*net.IPNet); ok && ipnet.IP.To4() != nil {
-				hasIPv4 = true
-				break
-			}
-		}
-		if !hasIPv4 {
-			continue
-		}
+func (s *Sender) groupInterfaces() ([]net.Interface, error) {
+	if s.interfaces != nil {
+		return s.interfaces(s.addr, s.ifaceFilter)
+	}
+	return groupInterfaces(s.addr, s.ifaceFilter)
+}
 
-		log.Printf("Sending fragmented message via interface: %s", iface.Name)
+// retransmit sends the given fragments once more on every interface.
+func (s *Sender) retransmit(ctx context.Context, fragments [][]byte) error {
+	ifaces, err := s.groupInterfaces()
+	if err != nil {
+		return err
+	}
+	return s.transmitAll(ctx, ifaces, fragments, 1)
+}
 
-		go func(iface net.Interface, fragments [][]byte) {
-			conn, err := net.ListenPacket("udp4", "")
-			if err != nil {
-				log.Printf("[%s] failed to create UDP socket: %v", iface.Name, err)
-				return
-			}
-			defer conn.Close()
+func (s *Sender) transmitAll(ctx context.Context, ifaces []net.Interface, fragments [][]byte, repeats int) error {
 
-			p := ipv4.NewPacketConn(conn)
-			if err := p.SetMulticastInterface(&iface); err != nil {
-				log.Printf("[%s] failed to set multicast interface: %v", iface.Name, err)
-				return
-			}
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var errs []error
 
-			ticker := time.NewTicker(2 * time.Second)
-			defer ticker.Stop()
-
-			for {
-				select {
-				case <-ticker.C:
-					for _, fragment := range fragments {
-						_, err := p.WriteTo(fragment, nil, udpAddr)
-						if err != nil {
-							log.Printf("[%s] send fragment failed: %v", iface.Name, err)
-						} else {
-							log.Printf("[%s] sent fragment: %s", iface.Name, fragment)
-						}
-						time.Sleep(10 * time.Millisecond)
-					}
-				}
+	for _, iface := range ifaces {
+		log.Printf("Sending fragmented message via interface: %s", iface.Name)
+
+		wg.Add(1)
+		go func(iface net.Interface) {
+			defer wg.Done()
+			if err := s.transmit(ctx, iface, fragments, repeats); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("[%s] %w", iface.Name, err))
+				mu.Unlock()
 			}
-		}(iface, fragments)
+		}(iface)
 	}
+	wg.Wait()
 
-	return nil
+	return errors.Join(errs...)
 }
 
-func RunFragmentedSenderHostInfo(ctx context.Context, addr string, mtu int) error {
-	type hostInfoSender struct {
-		Hostname string   `json:"hostname"`
-		IPs      []string `json:"ips"`
+func (s *Sender) transmit(ctx context.Context, iface net.Interface, fragments [][]byte, repeats int) error {
+	conn, err := openSendConn(iface, s.addr, sendSocketOptions{
+		hopLimit:   s.hopLimit,
+		loopback:   s.loopback,
+		sendBuffer: s.sendBuffer,
+	})
+	if err != nil {
+		return err
 	}
+	defer conn.Close()
 
-	hostname, _ := os.Hostname()
-	msgID := fmt.Sprintf("%s-%d", hostname, time.Now().UnixNano())
+	dst := destination(s.addr, iface)
 
-	ifaces, err := net.Interfaces()
-	if err != nil {
-		return fmt.Errorf("failed to list interfaces: %w", err)
-	}
-
-	var localIPs []string
-	for _, ifaceAddr := range ifaces {
-		addrsI, _ := ifaceAddr.Addrs()
-		for _, a := range addrsI {
-			if ipnet, ok := a.(*net.IPNet); ok && ipnet.IP.To4() != nil {
-				if ipnet.IP.String() == "127.0.0.1" {
-					continue
-				}
-				localIPs = append(localIPs, ipnet.IP.String())
+	if repeats <= 0 {
+		repeats = 1
+	}
+
+	for i := 0; i < repeats; i++ {
+		for _, fragment := range fragments {
+			select {
+			case <-ctx.Done():
+				log.Printf("[%s] sender canceled", iface.Name)
+				return nil
+			default:
+			}
+
+			if _, err := conn.WriteTo(fragment, dst); err != nil {
+				log.Printf("[%s] send fragment failed: %v", iface.Name, err)
 			}
+			sleepContext(ctx, s.policy.FragmentGap)
 		}
+		sleepContext(ctx, s.policy.RepeatGap)
 	}
 
-	hostInfo := hostInfoSender{
-		Hostname: hostname,
-		IPs:      localIPs,
-	}
+	return nil
+}
 
-	msgBytes, err := json.Marshal(hostInfo)
-	if err != nil {
-		return fmt.Errorf("invalid data for marshalling: %w", err)
+func sleepContext(ctx context.Context, d time.Duration) {
+	if d <= 0 {
+		return
 	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+	case <-t.C:
+	}
+}
 
-	maxPayloadSize := mtu - 100
-	totalFragments := int(math.Ceil(float64(len(msgBytes)) / float64(maxPayloadSize)))
+func SendWithEnvelope(addr string, mtu int, typ string, payload interface{}) error {
+	return RunFragmentedSender(addr, mtu, MessageEnvelope{
+		Type:    typ,
+		Payload: payload,
+	})
+}
 
-	type Fragment struct {
-		MessageID string `json:"id"`
-		Seq       int    `json:"seq"`
-		Total     int    `json:"total"`
-		Data      []byte `json:"data"`
+// RunFragmentedSenderRequest sends a fragmented request message over UDP using multiple interfaces. (한번만 전송)
+func RunFragmentedSender(addr string, mtu int, data any) error {
+	s, err := NewSender(addr, mtu, WithSendPolicy(SendRepeated(3)))
+	if err != nil {
+		return err
 	}
+	return s.Start(context.Background(), data)
+}
 
-	var fragments [][]byte
-	for i := 0; i < totalFragments; i++ {
-		start := i * maxPayloadSize
-		end := start + maxPayloadSize
-		if end > len(msgBytes) {
-			end = len(msgBytes)
-		}
-
-		fragment := Fragment{
-			MessageID: msgID,
-			Seq:       i + 1,
-			Total:     totalFragments,
-			Data:      msgBytes[start:end],
-		}
+// RunFragmentedSender sends a fragmented message over UDP using multiple interfaces. (반복적으로 전송 특정 초 입력)
+func RunFragmentedSenderCicle(addr string, mtu int, data any, second time.Duration) error {
+	return RunFragmentedSenderCicleContext(context.Background(), addr, mtu, data, second)
+}
 
-		j, err := json.Marshal(fragment)
-		if err != nil {
-			return fmt.Errorf("failed to marshal fragment %d: %w", i+1, err)
-		}
-		fragments = append(fragments, j)
+// RunFragmentedSenderCicleContext is RunFragmentedSenderCicle that stops when ctx is cancelled.
+// Intervals below a millisecond (e.g. a bare 2 meant as seconds) fall back to
+// the previous fixed 2s.
+func RunFragmentedSenderCicleContext(ctx context.Context, addr string, mtu int, data any, interval time.Duration) error {
+	if interval < minSendInterval {
+		interval = defaultCicleInterval
 	}
-
-	udpAddr, err := net.ResolveUDPAddr("udp", addr)
+	s, err := NewSender(addr, mtu, WithSendPolicy(SendPeriodic(interval)))
 	if err != nil {
-		return fmt.Errorf("failed to resolve address %s: %w", addr, err)
+		return err
 	}
+	return s.Start(ctx, data)
+}
 
-	for _, iface := range ifaces {
-		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagMulticast == 0 {
-			continue
-		}
-
-		addrs, _ := iface.Addrs()
-		hasIPv4 := false
-		for _, addr := range addrs {
-			if ipnet, ok := addr.(*net.IPNet); ok && ipnet.IP.To4() != nil {
-				hasIPv4 = true
-				break
-			}
-		}
-		if !hasIPv4 {
-			continue
-		}
-
-		log.Printf("Sending fragmented message via interface: %s", iface.Name)
-
-		go func(iface net.Interface, fragments [][]byte) {
-			conn, err := net.ListenPacket("udp4", "")
-			if err != nil {
-				log.Printf("[%s] failed to create UDP socket: %v", iface.Name, err)
-				return
-			}
-			defer conn.Close()
+// RunFragmentedSenderHostInfo sends this host's name and IPs once; use Announce
+// to keep announcing them periodically.
+func RunFragmentedSenderHostInfo(ctx context.Context, addr string, mtu int) error {
+	type hostInfoSender struct {
+		Hostname string   `json:"hostname"`
+		IPs      []string `json:"ips"`
+	}
 
-			p := ipv4.NewPacketConn(conn)
-			if err := p.SetMulticastInterface(&iface); err != nil {
-				log.Printf("[%s] failed to set multicast interface: %v", iface.Name, err)
-				return
-			}
+	hostname, _ := os.Hostname()
 
-			select {
-			case <-ctx.Done():
-				log.Printf("[%s] sender canceled", iface.Name)
-				return
-			default:
-				for i := 0; i < 3; i++ {
-					for _, fragment := range fragments {
-						_, err := p.WriteTo(fragment, nil, udpAddr)
-						time.Sleep(10 * time.Millisecond)
-						if err != nil {
-							log.Printf("[%s] send fragment failed: %v", iface.Name, err)
-						} else {
-							log.Printf("[%s] sent fragment: %s", iface.Name, fragment)
-						}
-						time.Sleep(300 * time.Millisecond)
-					}
-				}
-			}
-		}(iface, fragments)
+	s, err := NewSender(addr, mtu, WithSendPolicy(SendRepeated(3)))
+	if err != nil {
+		return err
 	}
-
-	return nil
+	return s.Start(ctx, hostInfoSender{
+		Hostname: hostname,
+		IPs:      localIPs(false, nil),
+	})
 }
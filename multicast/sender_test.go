@@ -0,0 +1,148 @@
+package multicast
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestSendPolicies(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   SendPolicy
+		repeats  int
+		interval time.Duration
+	}{
+		{"once", SendOnce(), 1, 0},
+		{"repeated", SendRepeated(3), 3, 0},
+		{"periodic", SendPeriodic(time.Second), 1, time.Second},
+	}
+	for _, tt := range tests {
+		if tt.policy.Repeats != tt.repeats || tt.policy.Interval != tt.interval {
+			t.Errorf("%s: unexpected policy %+v", tt.name, tt.policy)
+		}
+	}
+}
+
+// roundCounter returns a sender that sends on no interface and records every
+// round in a retransmission window.
+func roundCounter(t *testing.T, policy SendPolicy) (*Sender, *retransmitWindow) {
+	t.Helper()
+	w := newRetransmitWindow(100, time.Minute)
+	s, err := NewSender("239.0.0.1:9999", 1500,
+		WithInterfaces(InterfaceFilter{Include: []string{"no-such-iface"}}),
+		WithSendPolicy(policy),
+		withRetransmitWindow(w))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, w
+}
+
+func TestSenderRounds(t *testing.T) {
+	s, w := roundCounter(t, SendOnce())
+	if err := s.Send(context.Background(), "hello"); err != nil {
+		t.Fatal(err)
+	}
+	if len(w.order) != 1 {
+		t.Errorf("send once ran %d rounds", len(w.order))
+	}
+
+	// 주기 전송은 취소될 때까지 매 라운드 새 ID 로
+	s, w = roundCounter(t, SendPeriodic(10*time.Millisecond))
+	ctx, cancel := context.WithTimeout(context.Background(), 55*time.Millisecond)
+	defer cancel()
+	if err := s.Send(ctx, "hello"); err != nil {
+		t.Fatal(err)
+	}
+	if ctx.Err() == nil {
+		t.Fatal("periodic send returned before cancellation")
+	}
+	if len(w.order) < 3 {
+		t.Errorf("periodic send ran only %d rounds", len(w.order))
+	}
+	seen := make(map[string]bool)
+	for _, id := range w.order {
+		if seen[id] {
+			t.Errorf("round reused message id %s", id)
+		}
+		seen[id] = true
+	}
+}
+
+func TestSenderRepeatsOnWire(t *testing.T) {
+	group := "239.0.0.82:9982"
+	addr, _ := net.ResolveUDPAddr("udp", group)
+	ifaces, err := groupInterfaces(addr, nil)
+	if err != nil || len(ifaces) == 0 {
+		t.Skip("no multicast interface available")
+	}
+	conn, err := net.ListenMulticastUDP("udp4", &ifaces[0], addr)
+	if err != nil {
+		t.Skipf("cannot join group: %v", err)
+	}
+	defer conn.Close()
+
+	s, err := NewSender(group, 1500,
+		WithInterfaces(InterfaceFilter{Include: []string{ifaces[0].Name}}),
+		WithSendPolicy(SendPolicy{Repeats: 3}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Send(context.Background(), "hello"); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, 2048)
+	received := 0
+	for {
+		conn.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+		if _, _, err := conn.ReadFromUDP(buf); err != nil {
+			break
+		}
+		received++
+	}
+	if received != 3 {
+		t.Errorf("received %d copies of a single fragment, want 3", received)
+	}
+}
+
+func TestSendIntervalValidated(t *testing.T) {
+	for _, interval := range []time.Duration{-time.Second, 2, time.Microsecond} {
+		if _, err := NewSender("239.0.0.1:9999", 1500, WithSendPolicy(SendPeriodic(interval))); err == nil {
+			t.Errorf("interval %s accepted", interval)
+		}
+	}
+	if _, err := NewSender("239.0.0.1:9999", 1500, WithSendPolicy(SendPeriodic(time.Millisecond))); err != nil {
+		t.Errorf("1ms interval rejected: %v", err)
+	}
+
+	// 기존 API 는 잘못된 주기를 예전 기본값 2초로 보정
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, interval := range []time.Duration{0, 2} {
+		if err := RunFragmentedSenderCicleContext(ctx, "239.0.0.1:9999", 1500, "hello", interval); err != nil {
+			t.Errorf("interval %s not clamped: %v", interval, err)
+		}
+	}
+}
+
+func TestPeriodicSendReenumeratesInterfaces(t *testing.T) {
+	s, w := roundCounter(t, SendPeriodic(10*time.Millisecond))
+	var lookups int
+	s.interfaces = func(group *net.UDPAddr, filter *InterfaceFilter) ([]net.Interface, error) {
+		lookups++
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 55*time.Millisecond)
+	defer cancel()
+	if err := s.Send(ctx, "hello"); err != nil {
+		t.Fatal(err)
+	}
+	// 라운드마다 인터페이스를 다시 조회
+	if lookups < 2 || lookups != len(w.order) {
+		t.Errorf("%d interface lookups for %d rounds", lookups, len(w.order))
+	}
+}
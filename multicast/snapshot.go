@@ -0,0 +1,132 @@
+package multicast
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// snapshotVersion is the format version written to snapshot files. Files
+// with any other version are ignored on load.
+const snapshotVersion = 1
+
+type snapshotConfig struct {
+	path     string
+	interval time.Duration
+}
+
+type snapshotFile struct {
+	Version int       `json:"version"`
+	SavedAt time.Time `json:"savedAt"`
+	Hosts   []Member  `json:"hosts"`
+}
+
+// WithSnapshot persists the host table to path every interval while the
+// receivers run, and once more when they stop. Init restores the table from
+// path; restored hosts are marked Stale until they announce themselves again.
+// With a zero interval the table is only restored.
+func WithSnapshot(path string, interval time.Duration) Option {
+	return func(n *Node) {
+		n.snapshot = &snapshotConfig{path: path, interval: interval}
+	}
+}
+
+// saveSnapshot writes the host table to path atomically: the data goes to a
+// temporary file in the same directory which then replaces path.
+func (n *Node) saveSnapshot(path string) error {
+	snap := snapshotFile{
+		Version: snapshotVersion,
+		SavedAt: time.Now(),
+	}
+	for _, m := range n.Members() {
+		snap.Hosts = append(snap.Hosts, m)
+	}
+
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close snapshot: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace snapshot: %w", err)
+	}
+	return nil
+}
+
+// loadSnapshot restores remote hosts from path as stale entries. Hosts that
+// are already known and the local host are left untouched.
+func (n *Node) loadSnapshot(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read snapshot: %w", err)
+	}
+
+	var snap snapshotFile
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return fmt.Errorf("failed to decode snapshot: %w", err)
+	}
+	if snap.Version != snapshotVersion {
+		return fmt.Errorf("unsupported snapshot version %d", snap.Version)
+	}
+
+	n.hostDataLock.Lock()
+	defer n.hostDataLock.Unlock()
+
+	n.restoredAt = time.Now()
+	restored := 0
+	for _, m := range snap.Hosts {
+		if m.Hostname == "" || m.Hostname == n.hostname {
+			continue
+		}
+		if _, ok := n.hostData[m.Hostname]; ok {
+			continue
+		}
+		m.Stale = true
+		n.hostData[m.Hostname] = m
+		n.subscribers.publish(MembershipEvent{Type: MemberJoined, Member: m}, n.stats)
+		restored++
+	}
+	log.Printf("📂 Restored %d hosts from snapshot %s (saved %s)", restored, path, snap.SavedAt.Format(time.RFC3339))
+	return nil
+}
+
+func (n *Node) runSnapshots(ctx context.Context) error {
+	ticker := time.NewTicker(n.snapshot.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return n.saveSnapshot(n.snapshot.path)
+		case <-ticker.C:
+			if err := n.saveSnapshot(n.snapshot.path); err != nil {
+				log.Printf("Snapshot failed: %v", err)
+			}
+		}
+	}
+}
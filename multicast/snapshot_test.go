@@ -0,0 +1,58 @@
+package multicast
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hosts.json")
+
+	a := NewNode(WithHostname("local"))
+	a.observe(HostInfoReceiver{Hostname: "local"}, time.Now())
+	a.observe(HostInfoReceiver{Hostname: "peer", Version: "1.0", Labels: map[string]string{"role": "db"}}, time.Now())
+	if err := a.saveSnapshot(path); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Errorf("temporary files left behind: %v", entries)
+	}
+
+	b := NewNode(WithHostname("local"), WithMemberTTL(time.Minute))
+	if err := b.loadSnapshot(path); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	peer, ok := b.Members()["peer"]
+	if !ok || !peer.Stale || peer.Version != "1.0" || peer.Labels["role"] != "db" {
+		t.Fatalf("unexpected restored peer %+v", peer)
+	}
+	if _, ok := b.Members()["local"]; ok {
+		t.Errorf("local host must not be restored from a snapshot")
+	}
+
+	b.expireMembers(time.Now().Add(30 * time.Second))
+	if _, ok := b.Members()["peer"]; !ok {
+		t.Fatalf("restored peer expired before the ttl since restore")
+	}
+
+	if !b.observe(HostInfoReceiver{Hostname: "peer", Version: "1.0", Labels: map[string]string{"role": "db"}}, time.Now()) {
+		t.Errorf("confirmation of a stale entry should count as a change")
+	}
+	if b.Members()["peer"].Stale {
+		t.Errorf("peer still stale after announcing itself")
+	}
+}
+
+func TestSnapshotRejectsUnknownVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hosts.json")
+	os.WriteFile(path, []byte(`{"version":99,"hosts":[]}`), 0o644)
+
+	if err := NewNode().loadSnapshot(path); err == nil {
+		t.Errorf("expected error for unknown snapshot version")
+	}
+	if err := NewNode().loadSnapshot(path + ".missing"); err != nil {
+		t.Errorf("missing snapshot should not be an error: %v", err)
+	}
+}
@@ -0,0 +1,58 @@
+package multicast
+
+import (
+	"sync"
+)
+
+// counters is a set of named monotonically increasing counters.
+type counters struct {
+	mu sync.Mutex
+	m  map[string]uint64
+}
+
+func newCounters() *counters {
+	return &counters{m: make(map[string]uint64)}
+}
+
+func (c *counters) inc(name string) {
+	c.mu.Lock()
+	c.m[name]++
+	c.mu.Unlock()
+}
+
+func (c *counters) snapshot() map[string]uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	copied := make(map[string]uint64, len(c.m))
+	for k, v := range c.m {
+		copied[k] = v
+	}
+	return copied
+}
+
+// Stats returns the node's counters, e.g. messages dropped per reason.
+func (n *Node) Stats() map[string]uint64 {
+	return n.stats.snapshot()
+}
+
+// dropError marks a message that was discarded; reason becomes the name of
+// the counter incremented for it.
+type dropError struct {
+	reason string
+	err    error
+}
+
+func (e *dropError) Error() string {
+	if e.err != nil {
+		return e.reason + ": " + e.err.Error()
+	}
+	return e.reason
+}
+
+func (e *dropError) Unwrap() error {
+	return e.err
+}
+
+func drop(reason string, err error) error {
+	return &dropError{reason: reason, err: err}
+}
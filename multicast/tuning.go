@@ -0,0 +1,219 @@
+package multicast
+
+import (
+	"fmt"
+	"time"
+)
+
+// Tuning holds the socket and timing knobs of a node's receivers and
+// senders. Start from DefaultTuning and adjust, e.g. larger buffers and
+// fewer repeats for jumbo-frame datacenter links, more repeats and longer
+// gaps for lossy Wi-Fi.
+type Tuning struct {
+	// ReadBufferSize is the largest datagram a receiver can read.
+	ReadBufferSize int
+	// SocketReceiveBuffer and SocketSendBuffer set SO_RCVBUF and SO_SNDBUF;
+	// zero keeps the OS default.
+	SocketReceiveBuffer int
+	SocketSendBuffer    int
+	// ReadDeadline bounds each socket read so receivers notice cancellation.
+	ReadDeadline time.Duration
+	// CleanupInterval is how often incomplete messages older than
+	// ReassemblyTimeout are discarded.
+	CleanupInterval   time.Duration
+	ReassemblyTimeout time.Duration
+	// DedupWindow is how long a dispatched message ID is remembered so its
+	// repeats and copies from other interfaces are dropped; zero disables it.
+	DedupWindow time.Duration
+	// DedupSize caps how many dispatched message IDs are remembered.
+	DedupSize int
+
+	// SendRepeats, FragmentGap and RepeatGap make up the send policy of
+	// messages sent through the node without reliable delivery.
+	SendRepeats int
+	FragmentGap time.Duration
+	RepeatGap   time.Duration
+	// FragmentOverhead is the room left in each JSON fragment for everything
+	// but the payload.
+	FragmentOverhead int
+
+	// MulticastTTL is the TTL (IPv4) or hop limit (IPv6) of sent datagrams;
+	// zero keeps the OS default of 1.
+	MulticastTTL int
+	// Loopback delivers sent datagrams to receivers on the same host.
+	Loopback bool
+}
+
+func DefaultTuning() Tuning {
+	return Tuning{
+		ReadBufferSize:      2048,
+		SocketReceiveBuffer: 2048,
+		ReadDeadline:        100 * time.Millisecond,
+		CleanupInterval:     5 * time.Second,
+		ReassemblyTimeout:   15 * time.Second,
+		DedupWindow:         30 * time.Second,
+		DedupSize:           4096,
+		SendRepeats:         3,
+		FragmentGap:         10 * time.Millisecond,
+		RepeatGap:           300 * time.Millisecond,
+		FragmentOverhead:    jsonFragmentOverhead,
+		Loopback:            true,
+	}
+}
+
+func (t Tuning) Validate() error {
+	switch {
+	case t.ReadBufferSize < 512 || t.ReadBufferSize > 65535:
+		return fmt.Errorf("read buffer size %d out of range [512, 65535]", t.ReadBufferSize)
+	case t.SocketReceiveBuffer < 0 || t.SocketSendBuffer < 0:
+		return fmt.Errorf("socket buffer sizes must not be negative")
+	case t.ReadDeadline <= 0:
+		return fmt.Errorf("read deadline must be positive")
+	case t.CleanupInterval <= 0 || t.ReassemblyTimeout <= 0:
+		return fmt.Errorf("cleanup interval and reassembly timeout must be positive")
+	case t.DedupWindow < 0:
+		return fmt.Errorf("dedup window must not be negative")
+	case t.DedupSize < 1:
+		return fmt.Errorf("dedup size must be at least 1")
+	case t.SendRepeats < 1:
+		return fmt.Errorf("send repeats must be at least 1")
+	case t.FragmentGap < 0 || t.RepeatGap < 0:
+		return fmt.Errorf("send gaps must not be negative")
+	case t.FragmentOverhead < 0:
+		return fmt.Errorf("fragment overhead must not be negative")
+	case t.MulticastTTL < 0 || t.MulticastTTL > 255:
+		return fmt.Errorf("multicast ttl %d out of range [0, 255]", t.MulticastTTL)
+	}
+	return nil
+}
+
+// WithTuning replaces all tunables of the node. It is validated when the
+// node starts receivers or creates senders.
+func WithTuning(t Tuning) Option {
+	return func(n *Node) {
+		n.tuning = t
+	}
+}
+
+// WithReadBufferSize sets the largest datagram receivers can read.
+func WithReadBufferSize(size int) Option {
+	return func(n *Node) {
+		n.tuning.ReadBufferSize = size
+	}
+}
+
+// WithSocketBuffers sets SO_RCVBUF of receivers and SO_SNDBUF of senders.
+func WithSocketBuffers(receive, send int) Option {
+	return func(n *Node) {
+		n.tuning.SocketReceiveBuffer = receive
+		n.tuning.SocketSendBuffer = send
+	}
+}
+
+// WithReadDeadline sets how long a receiver blocks in a single read.
+func WithReadDeadline(d time.Duration) Option {
+	return func(n *Node) {
+		n.tuning.ReadDeadline = d
+	}
+}
+
+// WithReassemblyTimeout discards incomplete messages older than timeout,
+// checking every cleanupInterval.
+func WithReassemblyTimeout(timeout, cleanupInterval time.Duration) Option {
+	return func(n *Node) {
+		n.tuning.ReassemblyTimeout = timeout
+		n.tuning.CleanupInterval = cleanupInterval
+	}
+}
+
+// WithDedupWindow sets how long the IDs of dispatched messages are remembered
+// to drop their repeats and copies from other interfaces. Zero disables it.
+func WithDedupWindow(window time.Duration) Option {
+	return func(n *Node) {
+		n.tuning.DedupWindow = window
+	}
+}
+
+// WithSendRepeats sets how often and how fast messages are repeated when
+// reliable delivery is off.
+func WithSendRepeats(repeats int, fragmentGap, repeatGap time.Duration) Option {
+	return func(n *Node) {
+		n.tuning.SendRepeats = repeats
+		n.tuning.FragmentGap = fragmentGap
+		n.tuning.RepeatGap = repeatGap
+	}
+}
+
+// WithFragmentOverhead sets the room reserved in each JSON fragment for
+// everything but the payload.
+func WithFragmentOverhead(bytes int) Option {
+	return func(n *Node) {
+		n.tuning.FragmentOverhead = bytes
+	}
+}
+
+// WithMulticastTTL sets the TTL (IPv4) or hop limit (IPv6) of sent datagrams.
+func WithMulticastTTL(ttl int) Option {
+	return func(n *Node) {
+		n.tuning.MulticastTTL = ttl
+	}
+}
+
+// WithLoopback enables or disables delivery of sent datagrams to receivers
+// on the same host.
+func WithLoopback(enabled bool) Option {
+	return func(n *Node) {
+		n.tuning.Loopback = enabled
+	}
+}
+
+// senderTuning returns the sender options derived from the node's tuning
+// and interface filter.
+func (n *Node) senderTuning() []SenderOption {
+	t := n.tuning
+	var fragmenter Fragmenter = &JSONFragmenter{MTU: n.mtu, Overhead: t.FragmentOverhead}
+	if n.wireFormat == WireBinary {
+		fragmenter = NewBinaryFragmenter(n.mtu)
+	}
+	opts := []SenderOption{
+		WithFragmenter(fragmenter),
+		WithHopLimit(t.MulticastTTL),
+		WithMulticastLoopback(t.Loopback),
+		WithSendBuffer(t.SocketSendBuffer),
+	}
+	if n.ifaceFilter != nil {
+		opts = append(opts, WithInterfaces(*n.ifaceFilter))
+	}
+	return opts
+}
+
+// validate checks the node's configuration before it touches the network.
+func (n *Node) validate() error {
+	if err := n.tuning.Validate(); err != nil {
+		return fmt.Errorf("invalid tuning: %w", err)
+	}
+	if n.wireFormat != WireJSON && n.wireFormat != WireBinary {
+		return fmt.Errorf("unknown wire format %d", n.wireFormat)
+	}
+	if n.mtu > n.tuning.ReadBufferSize {
+		return fmt.Errorf("mtu %d exceeds read buffer size %d", n.mtu, n.tuning.ReadBufferSize)
+	}
+	if n.ifaceFilter != nil {
+		if err := n.ifaceFilter.Validate(); err != nil {
+			return fmt.Errorf("invalid interface filter: %w", err)
+		}
+	}
+	if n.memberTTL < 0 || (n.memberTTL > 0 && n.memberTTL < minMemberTTL) {
+		return fmt.Errorf("member TTL %s is below %s", n.memberTTL, minMemberTTL)
+	}
+	if n.replay != nil && len(n.authKey) == 0 {
+		return fmt.Errorf("authentication key is empty")
+	}
+	if n.reliable != nil && n.reliable.NACKDelay < minNACKDelay {
+		return fmt.Errorf("NACK delay %s is below %s", n.reliable.NACKDelay, minNACKDelay)
+	}
+	if err := n.topicRoutesErr(); err != nil {
+		return err
+	}
+	return nil
+}
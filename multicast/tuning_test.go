@@ -0,0 +1,61 @@
+package multicast
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTuningValidation(t *testing.T) {
+	if err := DefaultTuning().Validate(); err != nil {
+		t.Fatalf("default tuning invalid: %v", err)
+	}
+
+	invalid := []Option{
+		WithReadBufferSize(100),
+		WithSocketBuffers(-1, 0),
+		WithReadDeadline(0),
+		WithReassemblyTimeout(0, time.Second),
+		WithSendRepeats(0, 0, 0),
+		WithFragmentOverhead(-1),
+		WithMulticastTTL(256),
+	}
+	for i, opt := range invalid {
+		n := NewNode(opt)
+		if err := n.tuning.Validate(); err == nil {
+			t.Errorf("option %d: expected validation error", i)
+		}
+		if _, err := n.NewSender("239.0.0.1:9999"); err == nil {
+			t.Errorf("option %d: sender created with invalid tuning", i)
+		}
+	}
+}
+
+func TestFragmentOverhead(t *testing.T) {
+	n := NewNode(WithMTU(9000), WithFragmentOverhead(200))
+	s, err := n.NewSender("239.0.0.1:9999")
+	if err != nil {
+		t.Fatalf("NewSender failed: %v", err)
+	}
+	f, ok := s.fragmenter.(*JSONFragmenter)
+	if !ok {
+		t.Fatalf("unexpected fragmenter %T", s.fragmenter)
+	}
+	if got, want := f.maxPayload(), (9000-200)/4*3; got != want {
+		t.Errorf("max payload %d, want %d", got, want)
+	}
+}
+
+func TestMTUFitsReadBuffer(t *testing.T) {
+	n := NewNode(WithMTU(9000))
+	if n.tuning.ReadBufferSize != 9000 {
+		t.Errorf("read buffer %d not raised to the mtu", n.tuning.ReadBufferSize)
+	}
+	if err := n.validate(); err != nil {
+		t.Errorf("jumbo mtu rejected: %v", err)
+	}
+
+	n = NewNode(WithMTU(9000), WithReadBufferSize(2048))
+	if err := n.validate(); err == nil {
+		t.Error("expected mtu larger than the read buffer to be rejected")
+	}
+}
@@ -1,6 +1,9 @@
 package multicast
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"fmt"
+)
 
 type HostInfoReceiver struct {
 	Version      string   `json:"version"`
@@ -10,9 +13,20 @@ type HostInfoReceiver struct {
 	IPs          []string `json:"ips"`
 	Endpoint     string   `json:"endpoint"`
 	EndpointPort int      `json:"endpointPort"`
+	// Labels are arbitrary application metadata, e.g. role or zone.
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+func (h HostInfoReceiver) Validate() error {
+	if h.Hostname == "" {
+		return fmt.Errorf("hostname is required")
+	}
+	return nil
 }
 
 type GenericMessage struct {
 	Type    string          `json:"type"`
 	Payload json.RawMessage `json:"payload"`
+
+	CorrelationID string `json:"correlationId,omitempty"`
 }
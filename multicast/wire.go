@@ -0,0 +1,216 @@
+package multicast
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"math"
+)
+
+// Binary fragment layout (big endian):
+//
+//	v1: magic(2) version(1) flags(1) idLen(1) id(idLen) seq(2) total(2) payloadLen(2) checksum(4) payload
+//	v2: magic(2) version(1) flags(1) idLen(1) id(idLen) seq(2) total(2) parity(2) payloadLen(2) checksum(4) payload
+//
+// Version 2 adds the FEC parity count and is only emitted for messages that
+// carry parity, so receivers that know just version 1 keep working.
+// The checksum is a CRC-32 (IEEE) of everything before it plus the payload.
+// JSON fragments always start with '{', so the magic lets a receiver accept
+// both formats on the same socket.
+const (
+	wireMagic     uint16 = 0xCA57
+	wireVersion   byte   = 1
+	wireVersionV2 byte   = 2
+
+	// binaryHeaderFixedSize is the v1 header size without the message ID.
+	binaryHeaderFixedSize = 2 + 1 + 1 + 1 + 2 + 2 + 2 + 4
+
+	// udpIPOverhead is the IPv6 + UDP header size, the larger of the two families.
+	udpIPOverhead = 48
+)
+
+func binaryHeaderSize(version byte, idLen int) int {
+	if version >= wireVersionV2 {
+		return binaryHeaderFixedSize + 2 + idLen
+	}
+	return binaryHeaderFixedSize + idLen
+}
+
+// WireFormat selects how a node's senders encode fragments. Receivers accept
+// both formats regardless.
+type WireFormat int
+
+const (
+	// WireJSON encodes fragments as JSON objects (the default).
+	WireJSON WireFormat = iota
+	// WireBinary encodes fragments with the compact binary header.
+	WireBinary
+)
+
+// WithWireFormat selects the fragment encoding of every sender of the node,
+// including announcements, replies and NACKs.
+func WithWireFormat(format WireFormat) Option {
+	return func(n *Node) {
+		n.wireFormat = format
+	}
+}
+
+// BinaryFragmenter encodes each fragment with the compact binary header.
+// A positive Redundancy adds ceil(fragments*Redundancy) FEC parity fragments.
+type BinaryFragmenter struct {
+	MTU        int
+	Redundancy float64
+}
+
+func NewBinaryFragmenter(mtu int) *BinaryFragmenter {
+	return &BinaryFragmenter{MTU: mtu}
+}
+
+func (f *BinaryFragmenter) withRedundancy(ratio float64) Fragmenter {
+	copied := *f
+	copied.Redundancy = ratio
+	return &copied
+}
+
+func (f *BinaryFragmenter) Fragment(msgID string, flags uint8, data []byte) ([][]byte, error) {
+	if len(msgID) > math.MaxUint8 {
+		return nil, fmt.Errorf("message id too long: %d bytes", len(msgID))
+	}
+
+	version := wireVersion
+	if f.Redundancy > 0 {
+		version = wireVersionV2
+	}
+	maxPayloadSize := f.MTU - udpIPOverhead - binaryHeaderSize(version, len(msgID))
+	if maxPayloadSize <= 0 {
+		return nil, fmt.Errorf("mtu %d is too small for fragmentation", f.MTU)
+	}
+
+	frags, err := splitFragments(msgID, flags, data, maxPayloadSize, f.Redundancy)
+	if err != nil {
+		return nil, err
+	}
+	if len(frags) > math.MaxUint16 {
+		return nil, fmt.Errorf("message needs %d fragments, limit is %d", len(frags), math.MaxUint16)
+	}
+
+	fragments := make([][]byte, 0, len(frags))
+	for _, frag := range frags {
+		b, err := encodeBinaryFragment(frag)
+		if err != nil {
+			return nil, err
+		}
+		fragments = append(fragments, b)
+	}
+	return fragments, nil
+}
+
+func encodeBinaryFragment(frag Fragment) ([]byte, error) {
+	if len(frag.MessageID) > math.MaxUint8 {
+		return nil, fmt.Errorf("message id too long: %d bytes", len(frag.MessageID))
+	}
+	if frag.Seq < 0 || frag.Seq > math.MaxUint16 || frag.Total < 0 || frag.Total > math.MaxUint16 {
+		return nil, fmt.Errorf("fragment %d/%d out of range", frag.Seq, frag.Total)
+	}
+	if frag.Parity < 0 || frag.Parity > frag.Total {
+		return nil, fmt.Errorf("fragment parity %d out of range", frag.Parity)
+	}
+	if len(frag.Data) > math.MaxUint16 {
+		return nil, fmt.Errorf("fragment payload too large: %d bytes", len(frag.Data))
+	}
+
+	version := wireVersion
+	if frag.Parity > 0 {
+		version = wireVersionV2
+	}
+
+	headerLen := binaryHeaderSize(version, len(frag.MessageID))
+	b := make([]byte, headerLen+len(frag.Data))
+
+	binary.BigEndian.PutUint16(b[0:], wireMagic)
+	b[2] = version
+	b[3] = frag.Flags
+	b[4] = byte(len(frag.MessageID))
+	off := 5 + copy(b[5:], frag.MessageID)
+	binary.BigEndian.PutUint16(b[off:], uint16(frag.Seq))
+	binary.BigEndian.PutUint16(b[off+2:], uint16(frag.Total))
+	off += 4
+	if version >= wireVersionV2 {
+		binary.BigEndian.PutUint16(b[off:], uint16(frag.Parity))
+		off += 2
+	}
+	binary.BigEndian.PutUint16(b[off:], uint16(len(frag.Data)))
+	copy(b[headerLen:], frag.Data)
+
+	sum := crc32.NewIEEE()
+	sum.Write(b[:off+2])
+	sum.Write(frag.Data)
+	binary.BigEndian.PutUint32(b[off+2:], sum.Sum32())
+
+	return b, nil
+}
+
+func decodeBinaryFragment(b []byte) (Fragment, error) {
+	if len(b) < binaryHeaderFixedSize {
+		return Fragment{}, fmt.Errorf("binary fragment too short: %d bytes", len(b))
+	}
+	version := b[2]
+	if version != wireVersion && version != wireVersionV2 {
+		return Fragment{}, fmt.Errorf("unsupported fragment version %d", version)
+	}
+
+	idLen := int(b[4])
+	headerLen := binaryHeaderSize(version, idLen)
+	if len(b) < headerLen {
+		return Fragment{}, fmt.Errorf("binary fragment header truncated")
+	}
+
+	off := 5 + idLen
+	frag := Fragment{
+		MessageID: string(b[5:off]),
+		Seq:       int(binary.BigEndian.Uint16(b[off:])),
+		Total:     int(binary.BigEndian.Uint16(b[off+2:])),
+		Flags:     b[3],
+	}
+	off += 4
+	if version >= wireVersionV2 {
+		frag.Parity = int(binary.BigEndian.Uint16(b[off:]))
+		off += 2
+	}
+
+	payloadLen := int(binary.BigEndian.Uint16(b[off:]))
+	if len(b) != headerLen+payloadLen {
+		return Fragment{}, fmt.Errorf("payload length mismatch: header says %d, got %d", payloadLen, len(b)-headerLen)
+	}
+
+	sum := crc32.NewIEEE()
+	sum.Write(b[:off+2])
+	sum.Write(b[headerLen:])
+	if sum.Sum32() != binary.BigEndian.Uint32(b[off+2:]) {
+		return Fragment{}, fmt.Errorf("fragment checksum mismatch")
+	}
+
+	// The read buffer is reused, so the payload must be copied out.
+	frag.Data = make([]byte, payloadLen)
+	copy(frag.Data, b[headerLen:])
+
+	return frag, nil
+}
+
+func isBinaryFragment(b []byte) bool {
+	return len(b) >= 2 && binary.BigEndian.Uint16(b) == wireMagic
+}
+
+// decodeFragment accepts both the binary and the legacy JSON wire format.
+func decodeFragment(b []byte) (Fragment, error) {
+	if isBinaryFragment(b) {
+		return decodeBinaryFragment(b)
+	}
+
+	var frag Fragment
+	if err := json.Unmarshal(b, &frag); err != nil {
+		return Fragment{}, fmt.Errorf("invalid fragment JSON: %w", err)
+	}
+	return frag, nil
+}
@@ -0,0 +1,109 @@
+package multicast
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestBinaryFragmentRoundTrip(t *testing.T) {
+	frag := Fragment{MessageID: "host-1-2", Seq: 2, Total: 3, Flags: 0x01, Data: []byte("payload")}
+
+	encoded, err := encodeBinaryFragment(frag)
+	if err != nil {
+		t.Fatalf("encode failed: %v", err)
+	}
+
+	decoded, err := decodeFragment(encoded)
+	if err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+
+	if decoded.MessageID != frag.MessageID || decoded.Seq != frag.Seq || decoded.Total != frag.Total || decoded.Flags != frag.Flags {
+		t.Errorf("header mismatch. Got: %+v, Expected: %+v", decoded, frag)
+	}
+	if !bytes.Equal(decoded.Data, frag.Data) {
+		t.Errorf("payload mismatch. Got: %q, Expected: %q", decoded.Data, frag.Data)
+	}
+}
+
+func TestBinaryFragmentChecksum(t *testing.T) {
+	encoded, err := encodeBinaryFragment(Fragment{MessageID: "id", Seq: 1, Total: 1, Data: []byte("payload")})
+	if err != nil {
+		t.Fatalf("encode failed: %v", err)
+	}
+
+	encoded[len(encoded)-1] ^= 0xFF
+	if _, err := decodeFragment(encoded); err == nil {
+		t.Errorf("expected checksum error for corrupted fragment")
+	}
+}
+
+func TestDecodeFragmentAcceptsJSON(t *testing.T) {
+	fragments, err := NewJSONFragmenter(1500).Fragment("json-id", 0, []byte(`{"type":"x"}`))
+	if err != nil {
+		t.Fatalf("fragment failed: %v", err)
+	}
+
+	decoded, err := decodeFragment(fragments[0])
+	if err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if decoded.MessageID != "json-id" || string(decoded.Data) != `{"type":"x"}` {
+		t.Errorf("unexpected fragment: %+v", decoded)
+	}
+}
+
+func TestFragmentersRespectMTU(t *testing.T) {
+	data := []byte(strings.Repeat("0123456789", 1000))
+	mtu := 1500
+
+	for name, f := range map[string]Fragmenter{
+		"json":   NewJSONFragmenter(mtu),
+		"binary": NewBinaryFragmenter(mtu),
+	} {
+		fragments, err := f.Fragment(newMessageID(), 0, data)
+		if err != nil {
+			t.Fatalf("%s: fragment failed: %v", name, err)
+		}
+
+		var reassembled []byte
+		for _, b := range fragments {
+			if len(b) > mtu {
+				t.Errorf("%s: datagram of %d bytes exceeds mtu %d", name, len(b), mtu)
+			}
+			frag, err := decodeFragment(b)
+			if err != nil {
+				t.Fatalf("%s: decode failed: %v", name, err)
+			}
+			reassembled = append(reassembled, frag.Data...)
+		}
+
+		if !bytes.Equal(reassembled, data) {
+			t.Errorf("%s: reassembled data does not match original", name)
+		}
+		t.Logf("%s: %d fragments", name, len(fragments))
+	}
+}
+
+func TestNodeWireFormat(t *testing.T) {
+	s, err := NewNode(WithWireFormat(WireBinary)).NewSender("239.0.0.1:9999")
+	if err != nil {
+		t.Fatalf("NewSender failed: %v", err)
+	}
+	if _, ok := s.fragmenter.(*BinaryFragmenter); !ok {
+		t.Errorf("expected binary fragmenter, got %T", s.fragmenter)
+	}
+
+	s, err = NewNode().NewSender("239.0.0.1:9999")
+	if err != nil {
+		t.Fatalf("NewSender failed: %v", err)
+	}
+	if _, ok := s.fragmenter.(*JSONFragmenter); !ok {
+		t.Errorf("expected JSON fragmenter by default, got %T", s.fragmenter)
+	}
+
+	if _, err := NewNode(WithWireFormat(WireFormat(7))).NewSender("239.0.0.1:9999"); err == nil {
+		t.Error("expected unknown wire format to be rejected")
+	}
+}
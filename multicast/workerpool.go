@@ -0,0 +1,225 @@
+package multicast
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"runtime/debug"
+	"sync"
+)
+
+// OverflowPolicy decides what happens to a message when the dispatch queue is full.
+type OverflowPolicy int
+
+const (
+	// DropNewest discards the message that did not fit.
+	DropNewest OverflowPolicy = iota
+	// DropOldest discards the longest queued message to make room.
+	DropOldest
+	// Block makes the receiver wait for room, which may lose fragments.
+	Block
+)
+
+// DispatchConfig configures the worker pool handlers run on. Receivers only
+// reassemble and decode messages; handlers run on Workers goroutines fed by
+// a queue of QueueSize messages. TypeLimits caps how many handlers of a
+// message type may run at once; messages over the limit wait (up to
+// QueueSize per type) without holding a worker.
+type DispatchConfig struct {
+	Workers    int
+	QueueSize  int
+	Overflow   OverflowPolicy
+	TypeLimits map[string]int
+}
+
+func DefaultDispatchConfig() DispatchConfig {
+	return DispatchConfig{
+		Workers:   4,
+		QueueSize: 256,
+		Overflow:  DropNewest,
+	}
+}
+
+// WithDispatch configures the handler worker pool. Zero fields fall back to
+// DefaultDispatchConfig.
+func WithDispatch(cfg DispatchConfig) Option {
+	defaults := DefaultDispatchConfig()
+	if cfg.Workers <= 0 {
+		cfg.Workers = defaults.Workers
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = defaults.QueueSize
+	}
+	return func(n *Node) {
+		n.dispatchConfig = cfg
+	}
+}
+
+// dispatchJob is one handler invocation.
+type dispatchJob struct {
+	ctx     context.Context
+	handler HandlerFunc
+	payload json.RawMessage
+	meta    Meta
+}
+
+// run invokes the handler, turning a panic into a logged and counted error.
+func (j dispatchJob) run(stats *counters) {
+	defer func() {
+		if r := recover(); r != nil {
+			stats.inc("handler_panics")
+			log.Printf("Handler for %s panicked: %v\n%s", j.meta.Type, r, debug.Stack())
+		}
+	}()
+
+	if err := j.handler(j.ctx, j.payload, j.meta); err != nil {
+		log.Printf("Handler error: %s", err)
+	}
+}
+
+// typeLimit tracks the running handlers of a limited message type. Jobs
+// beyond the limit are parked here instead of occupying a worker, and are run
+// by the worker that frees the slot.
+type typeLimit struct {
+	limit   int
+	running int
+	parked  []dispatchJob
+}
+
+type workerPool struct {
+	cfg      DispatchConfig
+	queue    chan dispatchJob
+	limits   map[string]*typeLimit
+	limitsMu sync.Mutex
+	stats    *counters
+	mu       sync.Mutex // serializes DropOldest evictions
+}
+
+func newWorkerPool(cfg DispatchConfig, stats *counters) *workerPool {
+	p := &workerPool{
+		cfg:    cfg,
+		queue:  make(chan dispatchJob, cfg.QueueSize),
+		limits: make(map[string]*typeLimit, len(cfg.TypeLimits)),
+		stats:  stats,
+	}
+	for msgType, limit := range cfg.TypeLimits {
+		if limit > 0 {
+			p.limits[msgType] = &typeLimit{limit: limit}
+		}
+	}
+	return p
+}
+
+// run starts the workers and waits for them to exit once ctx is done.
+// Messages still queued or parked at that point are discarded.
+func (p *workerPool) run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < p.cfg.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case job := <-p.queue:
+					p.execute(ctx, job)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// execute runs job unless its type is at its limit, in which case the job is
+// parked and the worker moves on. Whoever frees a slot runs the parked jobs
+// of that type, so a busy type never starves the others of workers.
+func (p *workerPool) execute(ctx context.Context, job dispatchJob) {
+	if !p.acquire(job) {
+		return
+	}
+	for {
+		job.run(p.stats)
+		next, ok := p.release(job.meta.Type)
+		if !ok || ctx.Err() != nil {
+			return
+		}
+		job = next
+	}
+}
+
+// acquire takes a slot for job's type, or parks job and reports false.
+func (p *workerPool) acquire(job dispatchJob) bool {
+	p.limitsMu.Lock()
+	defer p.limitsMu.Unlock()
+
+	l, ok := p.limits[job.meta.Type]
+	if !ok {
+		return true
+	}
+	if l.running < l.limit {
+		l.running++
+		return true
+	}
+	if len(l.parked) >= p.cfg.QueueSize {
+		p.stats.inc("dropped_queue_full")
+		log.Printf("Dispatch backlog for %s full, dropped message %s", job.meta.Type, job.meta.MessageID)
+		return false
+	}
+	l.parked = append(l.parked, job)
+	return false
+}
+
+// release hands the slot of msgType to its next parked job, if any.
+func (p *workerPool) release(msgType string) (dispatchJob, bool) {
+	p.limitsMu.Lock()
+	defer p.limitsMu.Unlock()
+
+	l, ok := p.limits[msgType]
+	if !ok {
+		return dispatchJob{}, false
+	}
+	if len(l.parked) > 0 {
+		next := l.parked[0]
+		l.parked = l.parked[1:]
+		return next, true
+	}
+	l.running--
+	return dispatchJob{}, false
+}
+
+// submit queues job according to the overflow policy.
+func (p *workerPool) submit(ctx context.Context, job dispatchJob) {
+	select {
+	case p.queue <- job:
+		return
+	default:
+	}
+
+	switch p.cfg.Overflow {
+	case Block:
+		select {
+		case p.queue <- job:
+		case <-ctx.Done():
+		}
+	case DropOldest:
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		for {
+			select {
+			case p.queue <- job:
+				return
+			default:
+			}
+			select {
+			case old := <-p.queue:
+				p.stats.inc("dropped_queue_oldest")
+				log.Printf("Dispatch queue full, dropped oldest %s message %s", old.meta.Type, old.meta.MessageID)
+			default:
+			}
+		}
+	default:
+		p.stats.inc("dropped_queue_full")
+		log.Printf("Dispatch queue full, dropped %s message %s", job.meta.Type, job.meta.MessageID)
+	}
+}
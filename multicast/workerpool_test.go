@@ -0,0 +1,155 @@
+package multicast
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func jobFor(id string, fn HandlerFunc) dispatchJob {
+	return dispatchJob{
+		ctx:     context.Background(),
+		handler: fn,
+		meta:    Meta{MessageID: id, Type: "test"},
+	}
+}
+
+func noopHandler(ctx context.Context, payload json.RawMessage, meta Meta) error { return nil }
+
+func TestWorkerPoolOverflowPolicies(t *testing.T) {
+	stats := newCounters()
+	p := newWorkerPool(DispatchConfig{Workers: 1, QueueSize: 2, Overflow: DropNewest}, stats)
+	for _, id := range []string{"1", "2", "3"} {
+		p.submit(context.Background(), jobFor(id, noopHandler))
+	}
+	if got := (<-p.queue).meta.MessageID; got != "1" {
+		t.Errorf("drop-newest kept the wrong message at the head: %s", got)
+	}
+	if stats.snapshot()["dropped_queue_full"] != 1 {
+		t.Errorf("expected one drop, got %v", stats.snapshot())
+	}
+
+	stats = newCounters()
+	p = newWorkerPool(DispatchConfig{Workers: 1, QueueSize: 2, Overflow: DropOldest}, stats)
+	for _, id := range []string{"1", "2", "3"} {
+		p.submit(context.Background(), jobFor(id, noopHandler))
+	}
+	if got := (<-p.queue).meta.MessageID; got != "2" {
+		t.Errorf("drop-oldest kept the wrong message at the head: %s", got)
+	}
+	if stats.snapshot()["dropped_queue_oldest"] != 1 {
+		t.Errorf("expected one eviction, got %v", stats.snapshot())
+	}
+
+	p = newWorkerPool(DispatchConfig{Workers: 1, QueueSize: 1, Overflow: Block}, newCounters())
+	p.submit(context.Background(), jobFor("1", noopHandler))
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	p.submit(ctx, jobFor("2", noopHandler))
+	if ctx.Err() == nil {
+		t.Errorf("block policy returned before the queue had room")
+	}
+}
+
+func TestWorkerPoolRecoversPanics(t *testing.T) {
+	stats := newCounters()
+	jobFor("1", func(ctx context.Context, payload json.RawMessage, meta Meta) error {
+		panic("boom")
+	}).run(stats)
+
+	if stats.snapshot()["handler_panics"] != 1 {
+		t.Errorf("expected panic to be counted, got %v", stats.snapshot())
+	}
+}
+
+func TestWorkerPoolTypeLimits(t *testing.T) {
+	p := newWorkerPool(DispatchConfig{Workers: 4, QueueSize: 16, TypeLimits: map[string]int{"test": 1}}, newCounters())
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		p.run(ctx)
+		close(done)
+	}()
+
+	var running, peak, finished atomic.Int32
+	slow := func(ctx context.Context, payload json.RawMessage, meta Meta) error {
+		cur := running.Add(1)
+		for {
+			old := peak.Load()
+			if cur <= old || peak.CompareAndSwap(old, cur) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		running.Add(-1)
+		finished.Add(1)
+		return nil
+	}
+	for i := 0; i < 4; i++ {
+		p.submit(ctx, jobFor("x", slow))
+	}
+	for deadline := time.Now().Add(time.Second); finished.Load() < 4 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	if finished.Load() != 4 {
+		t.Fatalf("only %d of 4 handlers ran", finished.Load())
+	}
+	if peak.Load() != 1 {
+		t.Errorf("type limit exceeded: %d handlers ran at once", peak.Load())
+	}
+}
+
+func TestWorkerPoolTypeLimitDoesNotStarveOthers(t *testing.T) {
+	p := newWorkerPool(DispatchConfig{Workers: 2, QueueSize: 16, TypeLimits: map[string]int{"x": 1}}, newCounters())
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		p.run(ctx)
+		close(done)
+	}()
+	release := make(chan struct{})
+	releaseAll := sync.OnceFunc(func() { close(release) })
+	defer func() {
+		releaseAll()
+		cancel()
+		<-done
+	}()
+
+	var limited atomic.Int32
+	blocked := func(ctx context.Context, payload json.RawMessage, meta Meta) error {
+		limited.Add(1)
+		<-release
+		return nil
+	}
+	for i := 0; i < 4; i++ {
+		job := jobFor("x", blocked)
+		job.meta.Type = "x"
+		p.submit(ctx, job)
+	}
+
+	// 제한된 타입이 막혀 있어도 다른 타입은 처리되어야 함
+	ran := make(chan struct{})
+	p.submit(ctx, jobFor("other", func(ctx context.Context, payload json.RawMessage, meta Meta) error {
+		close(ran)
+		return nil
+	}))
+	select {
+	case <-ran:
+	case <-time.After(time.Second):
+		t.Fatal("other message type starved by a limited type")
+	}
+
+	releaseAll()
+	for deadline := time.Now().Add(time.Second); limited.Load() < 4 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	if limited.Load() != 4 {
+		t.Errorf("only %d of 4 parked handlers ran", limited.Load())
+	}
+}